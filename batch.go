@@ -0,0 +1,155 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+// The minimum number of instances that a goroutine handles during batch
+// prediction. Smaller batches are not worth the overhead of goroutines.
+const minBatchChunk = 128
+
+// predictFunc predicts the label of an instance, storing per-class
+// values in values.
+type predictFunc func(m *linearModel, nodes []FeatureValue, values []float64) float64
+
+// SetNThreads sets the number of goroutines that are used by the batch
+// prediction methods. The default value is 0 and will use GOMAXPROCS
+// goroutines.
+func (model *Model) SetNThreads(nThreads int) {
+	model.nThreads = nThreads
+}
+
+// PredictBatch predicts the labels of a batch of instances. This is
+// more efficient than calling Predict for every instance, since
+// buffers are reused and instances are divided over goroutines. If the
+// model is closed, the labels are NaN.
+func (model *Model) PredictBatch(instances []FeatureVector) []float64 {
+	if model.model == nil {
+		labels := make([]float64, len(instances))
+		for i := range labels {
+			labels[i] = math.NaN()
+		}
+		return labels
+	}
+
+	labels, _ := model.predictBatch(instances, (*linearModel).predictValues, false)
+	return labels
+}
+
+// PredictDecisionValuesBatch predicts the labels of a batch of instances.
+// In contrast to PredictBatch, it also returns the per-label decision
+// values of each instance. Row i of the decision value matrix contains
+// the decision values of instance i, in the order of Labels(). The rows
+// share a single backing slice.
+func (model *Model) PredictDecisionValuesBatch(instances []FeatureVector) ([]float64, [][]float64, error) {
+	if model.model == nil {
+		return nil, nil, ErrClosed
+	}
+
+	labels, values := model.predictBatch(instances, (*linearModel).predictValues, true)
+	return labels, values, nil
+}
+
+// PredictProbabilityBatch predicts the labels of a batch of instances,
+// given a model with probability information. In contrast to
+// PredictBatch, it also returns the class probabilities of each instance.
+// Row i of the probability matrix contains the probabilities of instance
+// i, in the order of Labels(). The rows share a single backing slice.
+// Probability estimates are given for logistic regression and for models
+// with a calibrator. For other models, ErrNoProbabilities is returned,
+// see SupportsProbability.
+func (model *Model) PredictProbabilityBatch(instances []FeatureVector) ([]float64, [][]float64, error) {
+	if model.model == nil {
+		return nil, nil, ErrClosed
+	}
+
+	if c := model.calibrator; c != nil {
+		labels, values := model.predictBatch(instances, (*linearModel).predictValues, true)
+		for _, row := range values {
+			probs, err := c.Probabilities(row)
+			if err != nil {
+				return nil, nil, err
+			}
+			copy(row, probs)
+		}
+		return labels, values, nil
+	}
+
+	if !model.model.isProbability() {
+		return nil, nil, ErrNoProbabilities
+	}
+
+	labels, probs := model.predictBatch(instances, (*linearModel).predictProbability, true)
+	return labels, probs, nil
+}
+
+// predictBatch applies predict to every instance. If keepValues is true,
+// the values of each instance are returned as a matrix, otherwise a
+// scratch buffer is reused. The model should not be closed.
+func (model *Model) predictBatch(instances []FeatureVector, predict predictFunc, keepValues bool) ([]float64, [][]float64) {
+	m := model.model
+	nClasses := m.nrClass
+	labels := make([]float64, len(instances))
+
+	var matrix [][]float64
+	if keepValues {
+		data := make([]float64, len(instances)*nClasses)
+		matrix = make([][]float64, len(instances))
+		for i := range matrix {
+			matrix[i] = data[i*nClasses : (i+1)*nClasses : (i+1)*nClasses]
+		}
+	}
+
+	predictChunk := func(begin, end int) {
+		var scratch []float64
+		if !keepValues {
+			scratch = make([]float64, nClasses)
+		}
+
+		for i := begin; i < end; i++ {
+			values := scratch
+			if keepValues {
+				values = matrix[i]
+			}
+			labels[i] = predict(m, instances[i], values)
+		}
+	}
+
+	nThreads := model.nThreads
+	if nThreads <= 0 {
+		nThreads = runtime.GOMAXPROCS(0)
+	}
+
+	chunkSize := (len(instances) + nThreads - 1) / nThreads
+	if chunkSize < minBatchChunk {
+		chunkSize = minBatchChunk
+	}
+
+	if chunkSize >= len(instances) {
+		predictChunk(0, len(instances))
+		return labels, matrix
+	}
+
+	var wg sync.WaitGroup
+	for begin := 0; begin < len(instances); begin += chunkSize {
+		end := begin + chunkSize
+		if end > len(instances) {
+			end = len(instances)
+		}
+
+		wg.Add(1)
+		go func(begin, end int) {
+			defer wg.Done()
+			predictChunk(begin, end)
+		}(begin, end)
+	}
+	wg.Wait()
+
+	return labels, matrix
+}
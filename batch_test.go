@@ -0,0 +1,61 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"strings"
+	"testing"
+)
+
+func batchInstances(n int) []FeatureVector {
+	instances := make([]FeatureVector, n)
+	for i := range instances {
+		instances[i] = FeatureVector{{1, float64(i%7) - 3}, {2, float64(i%5) - 2}}
+	}
+	return instances
+}
+
+func TestPredictBatch(t *testing.T) {
+	model := readTestModel(t, strings.Replace(threeClassModel, "MCSVM_CS", "L2R_LR", 1))
+
+	for _, nThreads := range []int{0, 1, 3} {
+		model.SetNThreads(nThreads)
+
+		// Use enough instances to distribute them over goroutines.
+		instances := batchInstances(1000)
+
+		labels := model.PredictBatch(instances)
+		dvLabels, values, _ := model.PredictDecisionValuesBatch(instances)
+		probLabels, probs, _ := model.PredictProbabilityBatch(instances)
+
+		for i, instance := range instances {
+			label, checkValues, _ := model.PredictDecisionValuesSlice(instance)
+			_, checkProbs, _ := model.PredictProbabilitySlice(instance)
+
+			if labels[i] != label || dvLabels[i] != label || probLabels[i] != label {
+				t.Errorf("label(%d) = (%f, %f, %f), want %f", i, labels[i], dvLabels[i],
+					probLabels[i], label)
+			}
+
+			for j := range checkValues {
+				if values[i][j] != checkValues[j] {
+					t.Errorf("values[%d][%d] = %f, want %f", i, j, values[i][j], checkValues[j])
+				}
+
+				if probs[i][j] != checkProbs[j] {
+					t.Errorf("probs[%d][%d] = %f, want %f", i, j, probs[i][j], checkProbs[j])
+				}
+			}
+		}
+	}
+}
+
+func TestPredictBatchEmpty(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	if labels := model.PredictBatch(nil); len(labels) != 0 {
+		t.Errorf("len(PredictBatch(nil)) = %d, want 0", len(labels))
+	}
+}
@@ -0,0 +1,748 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// CalibrationMethod is a method to map decision values to probabilities.
+type CalibrationMethod int
+
+const (
+	// SigmoidCalibration fits a sigmoid to the decision values (Platt
+	// scaling).
+	SigmoidCalibration CalibrationMethod = iota
+
+	// IsotonicCalibration fits a non-decreasing function to the decision
+	// values using isotonic regression. Isotonic regression requires
+	// more calibration data than sigmoid calibration.
+	IsotonicCalibration
+)
+
+// A Calibrator maps the decision values of a classification model to
+// calibrated class probabilities. This makes it possible to obtain
+// probability estimates from support vector classifiers. For two-class
+// models, the probability of the first class is calibrated. For models
+// with more classes, the probability of each class is calibrated
+// (one-vs-rest) and the probabilities are normalized.
+//
+// A calibrator should be fitted on decision values of instances that
+// were not used to train the model, either using a held-out problem
+// (FitCalibrator) or using cross-validation (FitCalibratorFolds). A
+// calibrator can be added to a model using Model.SetCalibrator, so that
+// the model provides calibrated probabilities and the calibrator is
+// saved with the model.
+type Calibrator struct {
+	method CalibrationMethod
+	labels []int
+	maps   []calibrationMap
+}
+
+// calibrationMap maps the decision value of a class to its probability.
+type calibrationMap interface {
+	probability(value float64) float64
+	writeTo(w *bufio.Writer)
+}
+
+// NewCalibrator fits a calibrator. Row i of decisionValues contains the
+// decision values of instance i, in the order of labels. For two-class
+// models, only the first decision value of each row is used. targets
+// contains the label of each instance.
+func NewCalibrator(labels []int, decisionValues [][]float64, targets []float64, method CalibrationMethod) (*Calibrator, error) {
+	if len(labels) < 2 {
+		return nil, errors.New("Calibration requires at least two labels")
+	}
+
+	if len(decisionValues) != len(targets) {
+		return nil, fmt.Errorf("Number of decision values (%d) does not match the number of targets (%d)",
+			len(decisionValues), len(targets))
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("Calibration requires at least one instance")
+	}
+
+	nMaps := len(labels)
+	if nMaps == 2 {
+		nMaps = 1
+	}
+
+	for i, row := range decisionValues {
+		if len(row) < nMaps {
+			return nil, fmt.Errorf("Instance %d has %d decision values, expected %d",
+				i, len(row), nMaps)
+		}
+	}
+
+	c := &Calibrator{method: method, labels: append([]int(nil), labels...)}
+
+	values := make([]float64, len(targets))
+	positive := make([]bool, len(targets))
+	for class := 0; class < nMaps; class++ {
+		for i, row := range decisionValues {
+			values[i] = row[class]
+			positive[i] = int(targets[i]) == labels[class]
+		}
+
+		switch method {
+		case SigmoidCalibration:
+			c.maps = append(c.maps, fitSigmoid(values, positive))
+		case IsotonicCalibration:
+			c.maps = append(c.maps, fitIsotonic(values, positive))
+		default:
+			return nil, fmt.Errorf("Unknown calibration method: %d", method)
+		}
+	}
+
+	return c, nil
+}
+
+// FitCalibrator fits a calibrator on the decision values of the model
+// for the instances of a problem. The instances should not have been
+// used to train the model.
+func FitCalibrator(model *Model, problem *Problem, method CalibrationMethod) (*Calibrator, error) {
+	if err := checkCalibrationModel(model); err != nil {
+		return nil, err
+	}
+
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	var decisionValues [][]float64
+	var targets []float64
+	var err error
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		var values []float64
+		if _, values, err = model.PredictDecisionValuesSlice(instance.Features); err != nil {
+			return false
+		}
+		decisionValues = append(decisionValues, values)
+		targets = append(targets, instance.Label)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCalibrator(model.Labels(), decisionValues, targets, method)
+}
+
+// FitCalibratorFolds fits a calibrator using cross-validation. For each
+// fold, a model is trained on the remaining folds. The calibrator is
+// fitted on the decision values of the instances in each fold. folds[i]
+// is the fold of instance i, see CrossValidationFolds. The calibrator
+// can be used with a model that is trained on the complete problem.
+func FitCalibratorFolds(problem *Problem, param Parameters, folds []int, method CalibrationMethod) (*Calibrator, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if isRegressionSolver(param.SolverType.solverType) {
+		return nil, errors.New("Calibration requires a classification model")
+	}
+
+	if param.SolverType.solverType == solverOneClassSvm {
+		return nil, errOneClassCalibration
+	}
+
+	// Use the label order of a model trained on the complete problem.
+	labels := problemLabels(problem)
+
+	decisionValues := make([][]float64, len(folds))
+	err := forEachFold(context.Background(), problem, param, folds, func(fold int, model *Model, instances []FeatureVector, evalIndices []int) error {
+		// Map the label order of the fold model to that of the problem.
+		modelLabels := model.Labels()
+		if len(modelLabels) != len(labels) {
+			return fmt.Errorf("Fold %d: training data does not contain all labels", fold)
+		}
+
+		for _, idx := range evalIndices {
+			_, values, err := model.PredictDecisionValuesSlice(instances[idx])
+			if err != nil {
+				return err
+			}
+
+			decisionValues[idx] = reorderDecisionValues(labels, modelLabels, values)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCalibrator(labels, decisionValues, problem.targets(), method)
+}
+
+// reorderDecisionValues orders the decision values of a model with the
+// given model labels by labels.
+func reorderDecisionValues(labels, modelLabels []int, values []float64) []float64 {
+	ordered := make([]float64, len(labels))
+
+	// Two-class models have a single decision value for the first label.
+	if len(labels) == 2 {
+		if labels[0] == modelLabels[0] {
+			ordered[0] = values[0]
+		} else {
+			ordered[0] = -values[0]
+		}
+		return ordered
+	}
+
+	for i, label := range labels {
+		for j, modelLabel := range modelLabels {
+			if label == modelLabel {
+				ordered[i] = values[j]
+			}
+		}
+	}
+
+	return ordered
+}
+
+// Labels returns the labels of the calibrator, in the order of the
+// probabilities returned by Probabilities.
+func (c *Calibrator) Labels() []int {
+	return append([]int(nil), c.labels...)
+}
+
+// Method returns the calibration method.
+func (c *Calibrator) Method() CalibrationMethod {
+	return c.method
+}
+
+// Probabilities returns the calibrated probability of each class, given
+// the decision values of an instance in the order of Labels().
+func (c *Calibrator) Probabilities(decisionValues []float64) ([]float64, error) {
+	if len(decisionValues) < len(c.maps) {
+		return nil, fmt.Errorf("Got %d decision values, expected %d", len(decisionValues), len(c.maps))
+	}
+
+	probs := make([]float64, len(c.labels))
+
+	if len(c.labels) == 2 {
+		probs[0] = c.maps[0].probability(decisionValues[0])
+		probs[1] = 1 - probs[0]
+		return probs, nil
+	}
+
+	var sum float64
+	for i, m := range c.maps {
+		probs[i] = m.probability(decisionValues[i])
+		sum += probs[i]
+	}
+
+	for i := range probs {
+		if sum == 0 {
+			probs[i] = 1 / float64(len(probs))
+		} else {
+			probs[i] /= sum
+		}
+	}
+
+	return probs, nil
+}
+
+// PredictProbabilitySlice predicts the label of an instance using the
+// model, and returns the calibrated probability of each class in the
+// order of Labels(). The model should have the labels of the calibrator.
+func (c *Calibrator) PredictProbabilitySlice(model *Model, nodes []FeatureValue) (float64, []float64, error) {
+	if err := c.checkModel(model); err != nil {
+		return 0, nil, err
+	}
+
+	label, values, err := model.PredictDecisionValuesSlice(nodes)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	probs, err := c.Probabilities(values)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return label, probs, nil
+}
+
+// PredictProbability predicts the label of an instance using the
+// model, and returns a map with the calibrated probability of each
+// class. The model should have the labels of the calibrator.
+func (c *Calibrator) PredictProbability(model *Model, nodes []FeatureValue) (float64, map[int]float64, error) {
+	label, probs, err := c.PredictProbabilitySlice(model, nodes)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	probMap := make(map[int]float64)
+	for idx, label := range c.labels {
+		probMap[label] = probs[idx]
+	}
+
+	return label, probMap, nil
+}
+
+// Calibrator returns the calibrator of the model, or nil if the model
+// does not have a calibrator.
+func (model *Model) Calibrator() *Calibrator {
+	return model.calibrator
+}
+
+// SetCalibrator sets the calibrator of the model. The calibrator should
+// have the labels of the model. PredictProbability and the other
+// probability methods of the model then return calibrated probabilities,
+// and the calibrator is saved with the model. Setting the calibrator to
+// nil removes the calibrator.
+func (model *Model) SetCalibrator(c *Calibrator) error {
+	if c != nil {
+		if err := c.checkModel(model); err != nil {
+			return err
+		}
+	}
+
+	model.calibrator = c
+
+	return nil
+}
+
+// checkModel returns an error if the calibrator cannot be used with the
+// model.
+func (c *Calibrator) checkModel(model *Model) error {
+	if err := checkCalibrationModel(model); err != nil {
+		return err
+	}
+
+	if modelLabels := model.Labels(); !equalLabels(modelLabels, c.labels) {
+		return fmt.Errorf("Labels of the model (%v) do not match labels of the calibrator (%v)",
+			modelLabels, c.labels)
+	}
+
+	return nil
+}
+
+func checkCalibrationModel(model *Model) error {
+	if model.model == nil {
+		return ErrClosed
+	}
+
+	if model.model.isRegression() {
+		return errors.New("Calibration requires a classification model")
+	}
+
+	if model.model.isOneClass() {
+		return errOneClassCalibration
+	}
+
+	return nil
+}
+
+// One-class SVMs do not have labels for the instances, so there is no
+// target to calibrate against.
+var errOneClassCalibration = errors.New("Calibration is not supported for one-class SVMs")
+
+func equalLabels(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sigmoid calibration
+
+// sigmoidMap is the sigmoid 1 / (1 + exp(a * value + b)).
+type sigmoidMap struct {
+	a, b float64
+}
+
+func (m sigmoidMap) probability(value float64) float64 {
+	fApB := m.a*value + m.b
+	// Avoid overflow of exp.
+	if fApB >= 0 {
+		return math.Exp(-fApB) / (1 + math.Exp(-fApB))
+	}
+	return 1 / (1 + math.Exp(fApB))
+}
+
+func (m sigmoidMap) writeTo(w *bufio.Writer) {
+	fmt.Fprintf(w, "sigmoid %s %s\n", formatFloat(m.a), formatFloat(m.b))
+}
+
+// fitSigmoid fits a sigmoid using the algorithm of Lin, Lin, and Weng,
+// A note on Platt's probabilistic outputs for support vector machines,
+// 2007. This is a port of libsvm's sigmoid_train.
+func fitSigmoid(values []float64, positive []bool) sigmoidMap {
+	var prior1, prior0 float64
+	for _, p := range positive {
+		if p {
+			prior1++
+		} else {
+			prior0++
+		}
+	}
+
+	const (
+		maxIter = 100
+		minStep = 1e-10
+		sigma   = 1e-12
+		eps     = 1e-5
+	)
+
+	// Regularized targets, to avoid overfitting.
+	hiTarget := (prior1 + 1) / (prior1 + 2)
+	loTarget := 1 / (prior0 + 2)
+	t := make([]float64, len(values))
+	for i, p := range positive {
+		if p {
+			t[i] = hiTarget
+		} else {
+			t[i] = loTarget
+		}
+	}
+
+	objective := func(a, b float64) float64 {
+		var fval float64
+		for i, v := range values {
+			fApB := v*a + b
+			if fApB >= 0 {
+				fval += t[i]*fApB + math.Log(1+math.Exp(-fApB))
+			} else {
+				fval += (t[i]-1)*fApB + math.Log(1+math.Exp(fApB))
+			}
+		}
+		return fval
+	}
+
+	a := 0.0
+	b := math.Log((prior0 + 1) / (prior1 + 1))
+	fval := objective(a, b)
+
+	for iter := 0; iter < maxIter; iter++ {
+		// Gradient and Hessian, the Hessian is regularized by sigma.
+		h11, h22, h21 := sigma, sigma, 0.0
+		g1, g2 := 0.0, 0.0
+		for i, v := range values {
+			fApB := v*a + b
+			var p, q float64
+			if fApB >= 0 {
+				p = math.Exp(-fApB) / (1 + math.Exp(-fApB))
+				q = 1 / (1 + math.Exp(-fApB))
+			} else {
+				p = 1 / (1 + math.Exp(fApB))
+				q = math.Exp(fApB) / (1 + math.Exp(fApB))
+			}
+			d2 := p * q
+			h11 += v * v * d2
+			h22 += d2
+			h21 += v * d2
+			d1 := t[i] - p
+			g1 += v * d1
+			g2 += d1
+		}
+
+		if math.Abs(g1) < eps && math.Abs(g2) < eps {
+			break
+		}
+
+		// Newton direction.
+		det := h11*h22 - h21*h21
+		dA := -(h22*g1 - h21*g2) / det
+		dB := -(-h21*g1 + h11*g2) / det
+		gd := g1*dA + g2*dB
+
+		// Line search.
+		stepSize := 1.0
+		for stepSize >= minStep {
+			newA := a + stepSize*dA
+			newB := b + stepSize*dB
+			newF := objective(newA, newB)
+			if newF < fval+0.0001*stepSize*gd {
+				a, b, fval = newA, newB, newF
+				break
+			}
+			stepSize /= 2
+		}
+
+		if stepSize < minStep {
+			break
+		}
+	}
+
+	return sigmoidMap{a, b}
+}
+
+// Isotonic calibration
+
+// isotonicMap is a non-decreasing piecewise linear function through the
+// points (x[i], y[i]). Values outside the range of x are clamped.
+type isotonicMap struct {
+	x, y []float64
+}
+
+func (m isotonicMap) probability(value float64) float64 {
+	n := len(m.x)
+	if value <= m.x[0] {
+		return m.y[0]
+	}
+	if value >= m.x[n-1] {
+		return m.y[n-1]
+	}
+
+	i := sort.SearchFloat64s(m.x, value)
+	if m.x[i] == value {
+		return m.y[i]
+	}
+
+	// Interpolate between points i - 1 and i.
+	frac := (value - m.x[i-1]) / (m.x[i] - m.x[i-1])
+	return m.y[i-1] + frac*(m.y[i]-m.y[i-1])
+}
+
+func (m isotonicMap) writeTo(w *bufio.Writer) {
+	fmt.Fprintf(w, "isotonic %d", len(m.x))
+	for i := range m.x {
+		fmt.Fprintf(w, " %s %s", formatFloat(m.x[i]), formatFloat(m.y[i]))
+	}
+	w.WriteString("\n")
+}
+
+// fitIsotonic fits a non-decreasing function using the pool adjacent
+// violators algorithm. Each pooled block is represented by the mean of
+// its decision values and the fraction of positive instances.
+func fitIsotonic(values []float64, positive []bool) isotonicMap {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return values[order[i]] < values[order[j]]
+	})
+
+	type block struct {
+		sumX, sumY, n float64
+	}
+
+	var blocks []block
+	for _, idx := range order {
+		y := 0.0
+		if positive[idx] {
+			y = 1
+		}
+		blocks = append(blocks, block{values[idx], y, 1})
+
+		// Pool while the previous block has a higher mean, or the same
+		// decision value, so that the decision values of the blocks are
+		// strictly ascending.
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.sumY/prev.n < last.sumY/last.n && prev.sumX/prev.n < last.sumX/last.n {
+				break
+			}
+			blocks = blocks[:len(blocks)-1]
+			blocks[len(blocks)-1] = block{prev.sumX + last.sumX, prev.sumY + last.sumY, prev.n + last.n}
+		}
+	}
+
+	m := isotonicMap{make([]float64, len(blocks)), make([]float64, len(blocks))}
+	for i, b := range blocks {
+		m.x[i] = b.sumX / b.n
+		m.y[i] = b.sumY / b.n
+	}
+
+	return m
+}
+
+// Reading and writing
+
+// LoadCalibrator loads a previously saved calibrator.
+func LoadCalibrator(filename string) (*Calibrator, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := ReadCalibrator(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read calibrator %s: %s", filename, err.Error())
+	}
+
+	return c, nil
+}
+
+// ReadCalibrator reads a calibrator from a reader.
+func ReadCalibrator(r io.Reader) (*Calibrator, error) {
+	return readCalibrator(newWordScanner(r, "calibrator"))
+}
+
+// readCalibrator reads a calibrator, which is also stored in the
+// calibration section of a model file.
+func readCalibrator(s *wordScanner) (*Calibrator, error) {
+	if field, err := s.next("method"); err != nil {
+		return nil, err
+	} else if field != "method" {
+		return nil, fmt.Errorf("Expected method, got: %s", field)
+	}
+
+	c := &Calibrator{}
+	method, err := s.next("calibration method")
+	if err != nil {
+		return nil, err
+	}
+	switch method {
+	case "sigmoid":
+		c.method = SigmoidCalibration
+	case "isotonic":
+		c.method = IsotonicCalibration
+	default:
+		return nil, fmt.Errorf("Unknown calibration method: %s", method)
+	}
+
+	if field, err := s.next("nr_class"); err != nil {
+		return nil, err
+	} else if field != "nr_class" {
+		return nil, fmt.Errorf("Expected nr_class, got: %s", field)
+	}
+
+	nrClass, err := s.nextInt("number of classes")
+	if err != nil {
+		return nil, err
+	}
+	if nrClass < 2 {
+		return nil, fmt.Errorf("Calibrator should have at least two classes: %d", nrClass)
+	}
+
+	if field, err := s.next("label"); err != nil {
+		return nil, err
+	} else if field != "label" {
+		return nil, fmt.Errorf("Expected label, got: %s", field)
+	}
+
+	c.labels = make([]int, nrClass)
+	for i := range c.labels {
+		if c.labels[i], err = s.nextInt("label"); err != nil {
+			return nil, err
+		}
+	}
+
+	nMaps := nrClass
+	if nMaps == 2 {
+		nMaps = 1
+	}
+
+	for i := 0; i < nMaps; i++ {
+		if field, err := s.next(method); err != nil {
+			return nil, err
+		} else if field != method {
+			return nil, fmt.Errorf("Expected %s, got: %s", method, field)
+		}
+
+		switch c.method {
+		case SigmoidCalibration:
+			var m sigmoidMap
+			if m.a, err = s.nextFloat("sigmoid parameter"); err != nil {
+				return nil, err
+			}
+			if m.b, err = s.nextFloat("sigmoid parameter"); err != nil {
+				return nil, err
+			}
+			c.maps = append(c.maps, m)
+		case IsotonicCalibration:
+			n, err := s.nextInt("number of points")
+			if err != nil {
+				return nil, err
+			}
+			if n < 1 {
+				return nil, fmt.Errorf("Isotonic calibration should have at least one point: %d", n)
+			}
+
+			m := isotonicMap{make([]float64, n), make([]float64, n)}
+			for j := 0; j < n; j++ {
+				if m.x[j], err = s.nextFloat("decision value"); err != nil {
+					return nil, err
+				}
+				if m.y[j], err = s.nextFloat("probability"); err != nil {
+					return nil, err
+				}
+				if j > 0 && !(m.x[j] > m.x[j-1]) {
+					return nil, fmt.Errorf("Decision values of isotonic calibration should be strictly ascending: %s %s",
+						formatFloat(m.x[j-1]), formatFloat(m.x[j]))
+				}
+			}
+			c.maps = append(c.maps, m)
+		}
+	}
+
+	return c, nil
+}
+
+// Save the calibrator to a file.
+func (c *Calibrator) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New("Could not save calibrator to file: " + filename)
+	}
+
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		return errors.New("Could not save calibrator to file: " + filename)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.New("Could not save calibrator to file: " + filename)
+	}
+
+	return nil
+}
+
+// WriteTo writes the calibrator to a writer. The number of bytes written
+// is returned.
+func (c *Calibrator) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	c.write(bw)
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+// write writes the calibrator, which is also stored in the calibration
+// section of a model file.
+func (c *Calibrator) write(bw *bufio.Writer) {
+	switch c.method {
+	case SigmoidCalibration:
+		bw.WriteString("method sigmoid\n")
+	case IsotonicCalibration:
+		bw.WriteString("method isotonic\n")
+	}
+
+	fmt.Fprintf(bw, "nr_class %d\n", len(c.labels))
+
+	bw.WriteString("label")
+	for _, label := range c.labels {
+		fmt.Fprintf(bw, " %d", label)
+	}
+	bw.WriteString("\n")
+
+	for _, m := range c.maps {
+		m.writeTo(bw)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// calibrationData returns decision values that overlap for the two
+// labels, so that the probability increases with the decision value.
+func calibrationData() ([][]float64, []float64) {
+	var values [][]float64
+	var targets []float64
+	for i := -10; i <= 10; i++ {
+		v := float64(i) / 5
+		values = append(values, []float64{v, 0})
+		// Every third instance gets the label that the decision value
+		// does not suggest.
+		if (i > 0) != (i%3 == 0) {
+			targets = append(targets, 1)
+		} else {
+			targets = append(targets, -1)
+		}
+	}
+	return values, targets
+}
+
+func TestSigmoidCalibration(t *testing.T) {
+	values, targets := calibrationData()
+
+	c, err := NewCalibrator([]int{1, -1}, values, targets, SigmoidCalibration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := 0.0
+	for _, v := range []float64{-3, -1, 0, 1, 3} {
+		probs, err := c.Probabilities([]float64{v})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if probs[0] <= prev || probs[0] >= 1 {
+			t.Errorf("P(1 | %f) = %f, should be in (%f, 1)", v, probs[0], prev)
+		}
+
+		if math.Abs(probs[0]+probs[1]-1) > 1e-15 {
+			t.Errorf("Probabilities() = %v, should sum to 1", probs)
+		}
+
+		prev = probs[0]
+	}
+}
+
+func TestIsotonicCalibration(t *testing.T) {
+	values := [][]float64{{-2}, {-1}, {0}, {1}, {2}, {3}}
+	targets := []float64{-1, 1, -1, 1, 1, 1}
+
+	c, err := NewCalibrator([]int{1, -1}, values, targets, IsotonicCalibration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The violators at -1 and 0 are pooled, as are the equal values at
+	// 1, 2, and 3.
+	for _, check := range []struct{ value, prob float64 }{
+		{-3, 0}, {-2, 0}, {-1.25, 0.25}, {-0.5, 0.5}, {1, 0.8}, {2, 1}, {10, 1},
+	} {
+		probs, _ := c.Probabilities([]float64{check.value})
+		if math.Abs(probs[0]-check.prob) > 1e-12 {
+			t.Errorf("P(1 | %f) = %f, want %f", check.value, probs[0], check.prob)
+		}
+	}
+}
+
+func TestIsotonicCalibrationTies(t *testing.T) {
+	// The instances with decision value 0 are pooled, although they
+	// are not violators.
+	values := [][]float64{{0}, {0}, {1}}
+	targets := []float64{-1, 1, 1}
+
+	c, err := NewCalibrator([]int{1, -1}, values, targets, IsotonicCalibration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if x := c.maps[0].(isotonicMap).x; len(x) != 2 {
+		t.Errorf("Decision values: %v, want [0 1]", x)
+	}
+
+	if probs, _ := c.Probabilities([]float64{0}); probs[0] != 0.5 {
+		t.Errorf("P(1 | 0) = %f, want 0.5", probs[0])
+	}
+}
+
+func TestCalibratorPredictProbability(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	values := [][]float64{{2, 0, -2}, {-2, 2, 0}, {0, -2, 2}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	targets := []float64{3, 2, 1, 3, 2, 1}
+
+	c, err := NewCalibrator(model.Labels(), values, targets, SigmoidCalibration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label, probs, err := c.PredictProbability(model, FeatureVector{{1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if label != 3 {
+		t.Errorf("label = %f, want 3", label)
+	}
+
+	var sum float64
+	for _, p := range probs {
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-12 {
+		t.Errorf("PredictProbability() = %v, should sum to 1", probs)
+	}
+
+	if probs[3] <= probs[2] || probs[2] <= probs[1] {
+		t.Errorf("PredictProbability() = %v, want P(3) > P(2) > P(1)", probs)
+	}
+
+	// The calibrator cannot be used with a model with other labels.
+	if _, _, err := c.PredictProbability(readTestModel(t, binaryLRModel), FeatureVector{{1, 1}}); err == nil {
+		t.Error("Labels that do not match should be rejected")
+	}
+
+	if _, err := FitCalibrator(readTestModel(t, regressionModel), labelProblem([]float64{1}), SigmoidCalibration); err == nil {
+		t.Error("Regression models should be rejected")
+	}
+
+	if _, err := FitCalibrator(readTestModel(t, oneClassModel), labelProblem([]float64{1}), SigmoidCalibration); err == nil {
+		t.Error("One-class models should be rejected")
+	}
+}
+
+func TestFitCalibratorFolds(t *testing.T) {
+	problem := tenInstanceProblem(t)
+	param := DefaultParameters()
+	param.SolverType = NewL2RL2LossSvcDual(0.1)
+
+	folds, err := StratifiedFolds(problem, 2, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := FitCalibratorFolds(problem, param, folds, SigmoidCalibration)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := trainModel(t, param, problem)
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		label, probs, err := c.PredictProbabilitySlice(model, instance.Features)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The most probable label should be the predicted label.
+		best := 0
+		if probs[1] > probs[0] {
+			best = 1
+		}
+		if float64(c.Labels()[best]) != label {
+			t.Errorf("Most probable label %d, predicted %f (%v)", c.Labels()[best], label, probs)
+		}
+
+		return true
+	})
+
+	param.SolverType = NewOneClassSvm(0.1, 0.01)
+	if _, err := FitCalibratorFolds(problem, param, folds, SigmoidCalibration); err == nil {
+		t.Error("One-class SVMs should be rejected")
+	}
+}
+
+func TestCalibratorWriteRead(t *testing.T) {
+	values, targets := calibrationData()
+
+	for _, method := range []CalibrationMethod{SigmoidCalibration, IsotonicCalibration} {
+		c, err := NewCalibrator([]int{1, -1}, values, targets, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if _, err := c.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		c2, err := ReadCalibrator(strings.NewReader(buf.String()))
+		if err != nil {
+			t.Fatalf("Could not read calibrator: %s\n%s", err, buf.String())
+		}
+
+		if c2.Method() != method {
+			t.Errorf("Method() = %d, want %d", c2.Method(), method)
+		}
+
+		for _, v := range []float64{-3, -0.3, 0, 0.7, 3} {
+			p1, _ := c.Probabilities([]float64{v})
+			p2, _ := c2.Probabilities([]float64{v})
+			if p1[0] != p2[0] {
+				t.Errorf("P(1 | %f) = %f after reading, want %f", v, p2[0], p1[0])
+			}
+		}
+	}
+}
+
+func TestReadCalibratorIsotonicOrder(t *testing.T) {
+	for _, calibrator := range []string{
+		"method isotonic\nnr_class 2\nlabel 1 -1\nisotonic 3 -1 0 1 0.5 0 1\n",
+		"method isotonic\nnr_class 2\nlabel 1 -1\nisotonic 2 0 0 0 1\n",
+	} {
+		if _, err := ReadCalibrator(strings.NewReader(calibrator)); err == nil {
+			t.Errorf("Decision values that are not strictly ascending should be rejected:\n%s", calibrator)
+		}
+	}
+
+	calibrator := "method isotonic\nnr_class 2\nlabel 1 -1\nisotonic 2 0 0 1 1\n"
+	if _, err := ReadCalibrator(strings.NewReader(calibrator)); err != nil {
+		t.Errorf("Could not read calibrator: %s", err)
+	}
+}
+
+func TestModelCalibrator(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+	if model.SupportsProbability() {
+		t.Fatal("Model without a calibrator should not support probabilities")
+	}
+
+	values := [][]float64{{2, 0, -2}, {-2, 2, 0}, {0, -2, 2}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	targets := []float64{3, 2, 1, 3, 2, 1}
+
+	c, err := NewCalibrator(model.Labels(), values, targets, IsotonicCalibration)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := readTestModel(t, binaryLRModel).SetCalibrator(c); err == nil {
+		t.Error("Calibrator with other labels should be rejected")
+	}
+
+	if err := model.SetCalibrator(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !model.SupportsProbability() {
+		t.Error("Model with a calibrator should support probabilities")
+	}
+
+	// The calibrator is saved with the model.
+	var buf bytes.Buffer
+	if _, err := model.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	model2, err := ReadModel(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model2.Calibrator() == nil {
+		t.Fatal("Calibrator was not read")
+	}
+
+	instances := []FeatureVector{{{1, 1}}, {{2, 1}}, {{1, 0.5}, {2, 0.5}}}
+	_, batchProbs, err := model2.PredictProbabilityBatch(instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, instance := range instances {
+		wantLabel, want, err := c.PredictProbabilitySlice(model, instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		label, probs, err := model2.PredictProbabilitySlice(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if label != wantLabel {
+			t.Errorf("PredictProbabilitySlice() label = %f, want %f", label, wantLabel)
+		}
+		for j := range want {
+			if probs[j] != want[j] || batchProbs[i][j] != want[j] {
+				t.Errorf("Probabilities %v and %v, want %v", probs, batchProbs[i], want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import "context"
+
+// TrainModelContext trains a model like TrainModel, but returns the
+// context's error if the context is cancelled or its deadline is
+// exceeded. The context is checked before training starts and after
+// training has finished. liblinear cannot be stopped while it is
+// solving, so a cancelled context does not shorten training that has
+// already started: the function returns when liblinear returns, and
+// the trained model is discarded.
+func TrainModelContext(ctx context.Context, param Parameters, problem *Problem) (*Model, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	model, err := TrainModel(param, problem)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		model.Close()
+		return nil, err
+	}
+
+	return model, nil
+}
+
+// CrossValidationContext performs nFolds-fold cross-validation, but
+// returns the context's error if the context is cancelled or its deadline
+// is exceeded. The context is checked before the model of each fold is
+// trained. liblinear cannot be stopped while it is solving, so after
+// cancellation, the function returns once the model of the current fold
+// is trained.
+//
+// In contrast to CrossValidation, which lets liblinear assign instances
+// to folds randomly, the folds are assigned using RandomFolds with seed
+// 1. The result is the same as that of CrossValidationFolds with these
+// folds. The slice that is returned contains the predicted instance
+// classes.
+func CrossValidationContext(ctx context.Context, problem *Problem, param Parameters, nFolds uint) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	folds, err := RandomFolds(problem, nFolds, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return crossValidationFolds(ctx, problem, param, folds)
+}
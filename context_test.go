@@ -0,0 +1,104 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrainModelContext(t *testing.T) {
+	problem := simpleProblem(t)
+
+	model, err := TrainModelContext(context.Background(), DefaultParameters(), problem)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
+
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		if label := model.Predict(instance.Features); label != instance.Label {
+			t.Errorf("Predict() = %f, want %f", label, instance.Label)
+		}
+		return true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := TrainModelContext(ctx, DefaultParameters(), problem); err != context.Canceled {
+		t.Errorf("TrainModelContext() = %v, want %v", err, context.Canceled)
+	}
+
+	// Cancelled while training.
+	if _, err := TrainModelContext(&countdownContext{context.Background(), 1}, DefaultParameters(), problem); err != context.Canceled {
+		t.Errorf("TrainModelContext() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCrossValidationContext(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	predictions, err := CrossValidationContext(context.Background(), problem, DefaultParameters(), 10)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal("Could not perform cross-validation: " + err.Error())
+	}
+
+	if len(predictions) != 10 {
+		t.Errorf("len(predictions) = %d, want 10", len(predictions))
+	}
+
+	folds, err := RandomFolds(problem, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := CrossValidationFolds(problem, DefaultParameters(), folds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if predictions[i] != want[i] {
+			t.Errorf("CrossValidationContext() = %v, want %v", predictions, want)
+			break
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := CrossValidationContext(ctx, problem, DefaultParameters(), 10); err != context.Canceled {
+		t.Errorf("CrossValidationContext() = %v, want %v", err, context.Canceled)
+	}
+}
+
+// countdownContext is cancelled after its error was checked n times.
+type countdownContext struct {
+	context.Context
+	n int
+}
+
+func (ctx *countdownContext) Err() error {
+	if ctx.n == 0 {
+		return context.Canceled
+	}
+
+	ctx.n--
+	return nil
+}
+
+func TestCrossValidationContextCancelFold(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	// Cancelled after training the first two folds.
+	ctx := &countdownContext{context.Background(), 3}
+	if _, err := CrossValidationContext(ctx, problem, DefaultParameters(), 10); err != context.Canceled && err != ErrTrainingUnavailable {
+		t.Errorf("CrossValidationContext() = %v, want %v", err, context.Canceled)
+	}
+}
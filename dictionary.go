@@ -0,0 +1,496 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A LabelDictionary maps string labels to the numeric labels that are
+// used by liblinear. Numeric labels are assigned in order of first
+// occurrence, starting at zero.
+type LabelDictionary struct {
+	labels []string
+	ids    map[string]int
+}
+
+// NewLabelDictionary creates an empty label dictionary.
+func NewLabelDictionary() *LabelDictionary {
+	return &LabelDictionary{ids: make(map[string]int)}
+}
+
+// Add returns the numeric label of a string label. If the dictionary
+// does not contain the label, it is added.
+func (d *LabelDictionary) Add(label string) int {
+	if id, ok := d.ids[label]; ok {
+		return id
+	}
+
+	id := len(d.labels)
+	d.labels = append(d.labels, label)
+	d.ids[label] = id
+
+	return id
+}
+
+// Lookup returns the numeric label of a string label. ok is false if the
+// dictionary does not contain the label.
+func (d *LabelDictionary) Lookup(label string) (id int, ok bool) {
+	id, ok = d.ids[label]
+	return
+}
+
+// Label returns the string label of a numeric label. ok is false if the
+// dictionary does not contain the numeric label.
+func (d *LabelDictionary) Label(id int) (label string, ok bool) {
+	if id < 0 || id >= len(d.labels) {
+		return "", false
+	}
+
+	return d.labels[id], true
+}
+
+// Labels returns the string labels, ordered by their numeric labels.
+func (d *LabelDictionary) Labels() []string {
+	return append([]string(nil), d.labels...)
+}
+
+// Len returns the number of labels in the dictionary.
+func (d *LabelDictionary) Len() int {
+	return len(d.labels)
+}
+
+func (d *LabelDictionary) clone() *LabelDictionary {
+	c := NewLabelDictionary()
+	for _, label := range d.labels {
+		c.Add(label)
+	}
+	return c
+}
+
+// A LabeledInstance is a training instance with a string label.
+type LabeledInstance struct {
+	Label    string
+	Features FeatureVector
+	Weight   float64
+}
+
+// AddLabeled adds a training instance with a string label to the
+// problem. The label is mapped to a numeric label using the label
+// dictionary of the problem. If the problem does not have a label
+// dictionary yet, an empty dictionary is created.
+func (problem *Problem) AddLabeled(instance LabeledInstance) error {
+	if err := problem.checkOpen(); err != nil {
+		return err
+	}
+
+	if problem.labelDict == nil {
+		problem.labelDict = NewLabelDictionary()
+	}
+
+	_, known := problem.labelDict.Lookup(instance.Label)
+	id := problem.labelDict.Add(instance.Label)
+
+	err := problem.Add(TrainingInstance{
+		Label:    float64(id),
+		Features: instance.Features,
+		Weight:   instance.Weight,
+	})
+
+	// Do not keep labels of instances that were rejected.
+	if err != nil && !known {
+		delete(problem.labelDict.ids, instance.Label)
+		problem.labelDict.labels = problem.labelDict.labels[:id]
+	}
+
+	return err
+}
+
+// LabelDictionary returns the label dictionary of the problem, or nil
+// if the problem does not have a label dictionary.
+func (problem *Problem) LabelDictionary() *LabelDictionary {
+	return problem.labelDict
+}
+
+// SetLabelDictionary sets the label dictionary that is used by
+// AddLabeled. Models that are trained on the problem get a copy of the
+// dictionary.
+func (problem *Problem) SetLabelDictionary(d *LabelDictionary) {
+	problem.labelDict = d
+}
+
+// LabelDictionary returns the label dictionary of the model, or nil if
+// the model does not have a label dictionary.
+func (model *Model) LabelDictionary() *LabelDictionary {
+	return model.labelDict
+}
+
+// SetLabelDictionary sets the label dictionary of the model. The
+// dictionary is saved with the model.
+func (model *Model) SetLabelDictionary(d *LabelDictionary) {
+	model.labelDict = d
+}
+
+// PredictLabel predicts the string label of an instance. The model
+// should have a label dictionary.
+func (model *Model) PredictLabel(nodes []FeatureValue) (string, error) {
+	if model.model == nil {
+		return "", ErrClosed
+	}
+
+	return model.stringLabel(model.Predict(nodes))
+}
+
+// PredictLabelProbability predicts the string label of an instance and
+// returns the probability of each string label. The model should have a
+// label dictionary and provide probability estimates.
+func (model *Model) PredictLabelProbability(nodes []FeatureValue) (string, map[string]float64, error) {
+	label, probs, err := model.PredictProbabilitySlice(nodes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	strLabel, err := model.stringLabel(label)
+	if err != nil {
+		return "", nil, err
+	}
+
+	probMap := make(map[string]float64)
+	for idx, label := range model.model.labels {
+		s, err := model.stringLabel(float64(label))
+		if err != nil {
+			return "", nil, err
+		}
+		probMap[s] = probs[idx]
+	}
+
+	return strLabel, probMap, nil
+}
+
+// stringLabel maps a numeric label to a string label using the label
+// dictionary.
+func (model *Model) stringLabel(label float64) (string, error) {
+	if model.labelDict == nil {
+		return "", ErrNoLabelDictionary
+	}
+
+	s, ok := model.labelDict.Label(int(label))
+	if !ok {
+		return "", fmt.Errorf("Label %d is not in the label dictionary", int(label))
+	}
+
+	return s, nil
+}
+
+// A FeatureDictionary maps feature names to feature indices. Indices are
+// assigned in order of first occurrence, starting at one. After the
+// dictionary is frozen, no new features are added and unknown features
+// are ignored, as is required for prediction.
+type FeatureDictionary struct {
+	names  []string
+	ids    map[string]int
+	frozen bool
+}
+
+// NewFeatureDictionary creates an empty feature dictionary.
+func NewFeatureDictionary() *FeatureDictionary {
+	return &FeatureDictionary{ids: make(map[string]int)}
+}
+
+// Index returns the index of a feature. If the dictionary does not
+// contain the feature and is not frozen, the feature is added. ok is
+// false if the feature is unknown and the dictionary is frozen.
+func (d *FeatureDictionary) Index(name string) (index int, ok bool) {
+	if index, ok := d.ids[name]; ok {
+		return index, true
+	}
+
+	if d.frozen {
+		return 0, false
+	}
+
+	d.names = append(d.names, name)
+	index = len(d.names)
+	d.ids[name] = index
+
+	return index, true
+}
+
+// Lookup returns the index of a feature, without adding the feature. ok
+// is false if the dictionary does not contain the feature.
+func (d *FeatureDictionary) Lookup(name string) (index int, ok bool) {
+	index, ok = d.ids[name]
+	return
+}
+
+// Name returns the name of the feature with the given index. ok is false
+// if the dictionary does not contain the index.
+func (d *FeatureDictionary) Name(index int) (name string, ok bool) {
+	if index < 1 || index > len(d.names) {
+		return "", false
+	}
+
+	return d.names[index-1], true
+}
+
+// Names returns the feature names, ordered by their indices.
+func (d *FeatureDictionary) Names() []string {
+	return append([]string(nil), d.names...)
+}
+
+// Len returns the number of features in the dictionary.
+func (d *FeatureDictionary) Len() int {
+	return len(d.names)
+}
+
+// Freeze freezes the dictionary, so that no new features are added.
+func (d *FeatureDictionary) Freeze() {
+	d.frozen = true
+}
+
+// Frozen returns true if the dictionary is frozen.
+func (d *FeatureDictionary) Frozen() bool {
+	return d.frozen
+}
+
+// Vector converts named feature values to a feature vector that is
+// sorted by index. Unknown features are added to the dictionary, unless
+// the dictionary is frozen. In that case, unknown features are ignored.
+func (d *FeatureDictionary) Vector(features map[string]float64) FeatureVector {
+	fv := make(FeatureVector, 0, len(features))
+	for name, value := range features {
+		if index, ok := d.Index(name); ok {
+			fv = append(fv, FeatureValue{index, value})
+		}
+	}
+
+	sort.Slice(fv, func(i, j int) bool {
+		return fv[i].Index < fv[j].Index
+	})
+
+	return fv
+}
+
+// frozenClone returns a frozen copy of the dictionary.
+func (d *FeatureDictionary) frozenClone() *FeatureDictionary {
+	c := NewFeatureDictionary()
+	for _, name := range d.names {
+		c.Index(name)
+	}
+	c.Freeze()
+	return c
+}
+
+// FeatureDictionary returns the feature dictionary of the problem, or
+// nil if the problem does not have a feature dictionary.
+func (problem *Problem) FeatureDictionary() *FeatureDictionary {
+	return problem.featureDict
+}
+
+// SetFeatureDictionary sets the feature dictionary of the problem. The
+// dictionary is not used by the problem itself: feature vectors are
+// constructed using FeatureDictionary.Vector. Models that are trained on
+// the problem get a frozen copy of the dictionary.
+func (problem *Problem) SetFeatureDictionary(d *FeatureDictionary) {
+	problem.featureDict = d
+}
+
+// FeatureDictionary returns the feature dictionary of the model, or nil
+// if the model does not have a feature dictionary.
+func (model *Model) FeatureDictionary() *FeatureDictionary {
+	return model.featureDict
+}
+
+// SetFeatureDictionary sets the feature dictionary of the model. The
+// dictionary is saved with the model.
+func (model *Model) SetFeatureDictionary(d *FeatureDictionary) {
+	model.featureDict = d
+}
+
+// WeightsByName extracts the weights of a two-class model by feature
+// name, see Weights. The model should have a feature dictionary.
+// Features that are not in the dictionary are omitted.
+func (model *Model) WeightsByName() (map[string]float64, error) {
+	weights, err := model.Weights()
+	if err != nil {
+		return nil, err
+	}
+
+	if model.featureDict == nil {
+		return nil, ErrNoFeatureDictionary
+	}
+
+	named := make(map[string]float64)
+	for i, w := range weights {
+		if name, ok := model.featureDict.Name(i + 1); ok {
+			named[name] = w
+		}
+	}
+
+	return named, nil
+}
+
+// encoders holds the optional encoders of a problem, which are copied to
+// the models that are trained on the problem.
+type encoders struct {
+	labelDict   *LabelDictionary
+	featureDict *FeatureDictionary
+	// Hashers are immutable and can be shared.
+	hasher *FeatureHasher
+	// Calibrators are immutable and can be shared. Only models have a
+	// calibrator.
+	calibrator *Calibrator
+}
+
+func (e encoders) clone() encoders {
+	c := encoders{hasher: e.hasher, calibrator: e.calibrator}
+	if e.labelDict != nil {
+		c.labelDict = e.labelDict.clone()
+	}
+	if e.featureDict != nil {
+		c.featureDict = e.featureDict.frozenClone()
+	}
+	return c
+}
+
+// writeTo writes the encoders as sections that follow the weights of a
+// model file. liblinear ignores these sections.
+func (e encoders) writeTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	if e.labelDict != nil {
+		fmt.Fprintf(bw, "label_dict %d\n", e.labelDict.Len())
+		for _, label := range e.labelDict.labels {
+			bw.WriteString(quoteField(label))
+			bw.WriteString("\n")
+		}
+	}
+
+	if e.featureDict != nil {
+		fmt.Fprintf(bw, "feature_dict %d\n", e.featureDict.Len())
+		for _, name := range e.featureDict.names {
+			bw.WriteString(quoteField(name))
+			bw.WriteString("\n")
+		}
+	}
+
+	if e.hasher != nil {
+		fmt.Fprintf(bw, "feature_hasher %d %d\n", e.hasher.bits, e.hasher.seed)
+	}
+
+	if e.calibrator != nil {
+		bw.WriteString("calibration\n")
+		e.calibrator.write(bw)
+	}
+
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+// readEncoders reads the sections that follow the weights of a model
+// file.
+func readEncoders(s *wordScanner) (encoders, error) {
+	var e encoders
+
+	for {
+		section, ok, err := s.scan()
+		if err != nil {
+			return e, err
+		}
+		if !ok {
+			return e, nil
+		}
+
+		switch section {
+		case "label_dict":
+			n, err := s.nextInt("number of labels")
+			if err != nil {
+				return e, err
+			}
+
+			e.labelDict = NewLabelDictionary()
+			for i := 0; i < n; i++ {
+				label, err := s.nextQuoted("label")
+				if err != nil {
+					return e, err
+				}
+				if e.labelDict.Add(label) != i {
+					return e, fmt.Errorf("Duplicate label in label dictionary: %s", label)
+				}
+			}
+		case "feature_dict":
+			n, err := s.nextInt("number of features")
+			if err != nil {
+				return e, err
+			}
+
+			e.featureDict = NewFeatureDictionary()
+			for i := 1; i <= n; i++ {
+				name, err := s.nextQuoted("feature name")
+				if err != nil {
+					return e, err
+				}
+				if index, _ := e.featureDict.Index(name); index != i {
+					return e, fmt.Errorf("Duplicate feature in feature dictionary: %s", name)
+				}
+			}
+			e.featureDict.Freeze()
+		case "feature_hasher":
+			bits, err := s.nextInt("number of hash bits")
+			if err != nil {
+				return e, err
+			}
+
+			seed, err := s.next("hash seed")
+			if err != nil {
+				return e, err
+			}
+			seedValue, err := strconv.ParseUint(seed, 10, 32)
+			if err != nil {
+				return e, fmt.Errorf("Cannot parse hash seed: %s", seed)
+			}
+
+			if bits < 0 {
+				return e, fmt.Errorf("Invalid number of hash bits: %d", bits)
+			}
+			if e.hasher, err = NewFeatureHasher(uint(bits), uint32(seedValue)); err != nil {
+				return e, err
+			}
+		case "calibration":
+			if e.calibrator, err = readCalibrator(s); err != nil {
+				return e, err
+			}
+		default:
+			return e, fmt.Errorf("Unknown section in %s file: %s", s.kind, section)
+		}
+	}
+}
+
+// quoteField quotes a string, such that it does not contain whitespace.
+func quoteField(s string) string {
+	return strings.ReplaceAll(strconv.QuoteToASCII(s), " ", `\x20`)
+}
+
+// nextQuoted reads a field that was quoted using quoteField.
+func (s *wordScanner) nextQuoted(what string) (string, error) {
+	field, err := s.next(what)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := strconv.Unquote(field)
+	if err != nil {
+		return "", fmt.Errorf("Cannot parse %s: %s", what, field)
+	}
+
+	return v, nil
+}
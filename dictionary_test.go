@@ -0,0 +1,211 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLabelDictionary(t *testing.T) {
+	d := NewLabelDictionary()
+
+	if id := d.Add("spam"); id != 0 {
+		t.Errorf("Add(spam) = %d, want 0", id)
+	}
+	if id := d.Add("ham"); id != 1 {
+		t.Errorf("Add(ham) = %d, want 1", id)
+	}
+	if id := d.Add("spam"); id != 0 {
+		t.Errorf("Add(spam) = %d, want 0", id)
+	}
+
+	if id, ok := d.Lookup("ham"); !ok || id != 1 {
+		t.Errorf("Lookup(ham) = (%d, %t), want (1, true)", id, ok)
+	}
+	if _, ok := d.Lookup("eggs"); ok {
+		t.Error("Lookup(eggs) should fail")
+	}
+
+	if label, ok := d.Label(1); !ok || label != "ham" {
+		t.Errorf("Label(1) = (%s, %t), want (ham, true)", label, ok)
+	}
+	if _, ok := d.Label(2); ok {
+		t.Error("Label(2) should fail")
+	}
+}
+
+func TestAddLabeled(t *testing.T) {
+	problem := NewProblem()
+	for _, inst := range []LabeledInstance{
+		{"sports", FromDenseVector([]float64{1, 0, 0}), 0},
+		{"politics", FromDenseVector([]float64{0, 1, 0}), 0},
+		{"science & tech", FromDenseVector([]float64{0, 0, 1}), 0},
+		{"sports", FromDenseVector([]float64{1, 0, 0.5}), 0},
+	} {
+		if err := problem.AddLabeled(inst); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rejected instances should not add labels.
+	if err := problem.AddLabeled(LabeledInstance{"other", FeatureVector{{0, 1}}, 0}); err == nil {
+		t.Fatal("Invalid feature indices should be rejected")
+	}
+
+	if n := problem.LabelDictionary().Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+
+	want := []float64{0, 1, 2, 0}
+	for i, label := range problem.targets() {
+		if label != want[i] {
+			t.Errorf("Label of instance %d = %f, want %f", i, label, want[i])
+		}
+	}
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+	model := trainModel(t, param, problem)
+
+	if label, err := model.PredictLabel(FromDenseVector([]float64{0, 0, 1})); err != nil || label != "science & tech" {
+		t.Errorf("PredictLabel() = (%s, %v), want science & tech", label, err)
+	}
+
+	label, probs, err := model.PredictLabelProbability(FromDenseVector([]float64{0, 1, 0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != "politics" || len(probs) != 3 || probs["politics"] < probs["sports"] {
+		t.Errorf("PredictLabelProbability() = (%s, %v), want politics", label, probs)
+	}
+
+	// The model has its own copy of the dictionary.
+	problem.LabelDictionary().Add("weather")
+	if n := model.LabelDictionary().Len(); n != 3 {
+		t.Errorf("Model dictionary Len() = %d, want 3", n)
+	}
+}
+
+func TestLabelDictionaryWriteRead(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	d := NewLabelDictionary()
+	d.Add("\"quoted\" label")
+	d.Add("naïve\tlabel")
+	model.SetLabelDictionary(d)
+
+	var buf bytes.Buffer
+	if _, err := model.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	model2 := readTestModel(t, buf.String())
+	labels := model2.LabelDictionary().Labels()
+	if len(labels) != 2 || labels[0] != d.labels[0] || labels[1] != d.labels[1] {
+		t.Errorf("Labels() = %q, want %q", labels, d.labels)
+	}
+}
+
+func TestPredictLabelNoDictionary(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	if _, err := model.PredictLabel(FeatureVector{{1, 1}}); !errors.Is(err, ErrNoLabelDictionary) {
+		t.Errorf("PredictLabel() = %v, want %v", err, ErrNoLabelDictionary)
+	}
+}
+
+func TestFeatureDictionary(t *testing.T) {
+	d := NewFeatureDictionary()
+
+	fv := d.Vector(map[string]float64{"a": 1, "beautiful": 1, "album": 1})
+	if len(fv) != 3 || fv[0].Index != 1 || fv[2].Index != 3 {
+		t.Errorf("Vector() = %v, want indices 1, 2, 3", fv)
+	}
+
+	if index, ok := d.Index("crappy"); !ok || index != 4 {
+		t.Errorf("Index(crappy) = (%d, %t), want (4, true)", index, ok)
+	}
+
+	d.Freeze()
+
+	// Unknown features are ignored after freezing.
+	if _, ok := d.Index("book"); ok {
+		t.Error("Index(book) should fail on a frozen dictionary")
+	}
+
+	fv = d.Vector(map[string]float64{"crappy": 2, "book": 1})
+	if len(fv) != 1 || fv[0] != (FeatureValue{4, 2}) {
+		t.Errorf("Vector() = %v, want [{4 2}]", fv)
+	}
+
+	if name, ok := d.Name(4); !ok || name != "crappy" {
+		t.Errorf("Name(4) = (%s, %t), want (crappy, true)", name, ok)
+	}
+	if _, ok := d.Name(0); ok {
+		t.Error("Name(0) should fail")
+	}
+}
+
+func TestFeatureDictionaryModel(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	d := NewFeatureDictionary()
+	d.Index("good")
+	d.Index("bad word")
+	model.SetFeatureDictionary(d)
+
+	var buf bytes.Buffer
+	if _, err := model.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	model = readTestModel(t, buf.String())
+	if !model.FeatureDictionary().Frozen() {
+		t.Error("Feature dictionary of a loaded model should be frozen")
+	}
+
+	weights, err := model.WeightsByName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weights) != 2 || weights["good"] != 2 || weights["bad word"] != -1 {
+		t.Errorf("WeightsByName() = %v, want map[bad word:-1 good:2]", weights)
+	}
+
+	e, err := model.Explain(model.FeatureDictionary().Vector(map[string]float64{"good": 1, "unknown": 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Contributions) != 1 || e.Contributions[0].Name != "good" {
+		t.Errorf("Contributions = %v, want a contribution of good", e.Contributions)
+	}
+
+	if _, err := readTestModel(t, binaryLRModel).WeightsByName(); !errors.Is(err, ErrNoFeatureDictionary) {
+		t.Errorf("WeightsByName() = %v, want %v", err, ErrNoFeatureDictionary)
+	}
+}
+
+func TestFeatureDictionaryTrain(t *testing.T) {
+	d := NewFeatureDictionary()
+	problem := NewProblem()
+	problem.SetFeatureDictionary(d)
+	problem.Add(TrainingInstance{Label: 0, Features: d.Vector(map[string]float64{"a": 1, "beautiful": 1, "album": 1})})
+	problem.Add(TrainingInstance{Label: 1, Features: d.Vector(map[string]float64{"a": 1, "crappy": 1, "ugly": 1, "album": 1})})
+
+	model := trainModel(t, DefaultParameters(), problem)
+
+	// The model has a frozen copy of the dictionary.
+	d.Index("book")
+	md := model.FeatureDictionary()
+	if !md.Frozen() || md.Len() != 5 {
+		t.Errorf("Model dictionary: Frozen() = %t, Len() = %d, want true, 5", md.Frozen(), md.Len())
+	}
+
+	if label := model.Predict(md.Vector(map[string]float64{"beautiful": 1, "book": 1})); label != 0 {
+		t.Errorf("Predict() = %f, want 0", label)
+	}
+}
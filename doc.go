@@ -1,9 +1,18 @@
 // Package golinear trains and applies linear classifiers.
 //
 // The package is a binding against liblinear with a Go-ish interface.
+// Models are trained using liblinear, prediction is implemented in Go.
+// When the package is built without cgo, models can be loaded and used
+// for prediction, but training is not available.
 // Trained models can be saved to and loaded from disk, to avoid the
 // (potentially) costly training process.
 //
+// liblinear cannot be interrupted while it trains a model. For this
+// reason, TrainModelContext and CrossValidationContext do not abort
+// promptly when their context is cancelled: training checks the context
+// before it starts and after it finishes, cross-validation checks it
+// between folds.
+//
 // A model is trained using a problem. A problem consists of training
 // instances, where each training instance has a class label and a feature
 // vector. The training procedure attempts to find one or more functions
@@ -35,8 +44,8 @@
 // We can now construct the problem using this representation:
 //
 //     problem := golinear.NewProblem()
-//     problem.Add(golinear.TrainingInstance{0, golinear.FromDenseVector([]float64{1, 1, 1, 0, 0})})
-//     problem.Add(golinear.TrainingInstance{1, golinear.FromDenseVector([]float64{1, 0, 1, 1, 1})})
+//     problem.Add(golinear.TrainingInstance{Label: 0, Features: golinear.FromDenseVector([]float64{1, 1, 1, 0, 0})})
+//     problem.Add(golinear.TrainingInstance{Label: 1, Features: golinear.FromDenseVector([]float64{1, 0, 1, 1, 1})})
 //
 // The problem is used to train a linear classifier using a set of parameters
 // to choose the type of solver, constraint violation cost, etc. We will use
@@ -68,4 +77,19 @@
 //     label := model.Predict(golinear.FromDenseVector([]float64{1, 1, 0, 0, 0}))
 //
 // As expected, the model will predict the sentence to be positive (0).
+//
+// Instead of assigning indices to words by hand, a FeatureDictionary can
+// be used to map feature names to indices. Similarly, class names can
+// be mapped to labels by adding instances with Problem.AddLabeled, which
+// uses a LabelDictionary. Models get the dictionaries of the problem they
+// were trained on, and the dictionaries are saved with the model:
+//
+//     dict := golinear.NewFeatureDictionary()
+//     problem.SetFeatureDictionary(dict)
+//     problem.AddLabeled(golinear.LabeledInstance{Label: "positive",
+//     	Features: dict.Vector(map[string]float64{"a": 1, "beautiful": 1, "album": 1})})
+//
+//     // After training, the dictionary of the model ignores unknown words.
+//     features := model.FeatureDictionary().Vector(map[string]float64{"beautiful": 1, "book": 1})
+//     label, err := model.PredictLabel(features)
 package golinear
@@ -0,0 +1,43 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import "errors"
+
+// ErrTrainingUnavailable is returned by functions that require liblinear
+// when golinear is built without cgo.
+var ErrTrainingUnavailable = errors.New("Training requires liblinear, golinear was built without cgo")
+
+// ErrClosed is returned when a problem or model is used after it was
+// closed.
+var ErrClosed = errors.New("Use of a closed problem or model")
+
+// ErrOutOfMemory is returned when memory could not be allocated for
+// liblinear.
+var ErrOutOfMemory = errors.New("Not enough memory")
+
+// ErrWeightsUnsupported is returned when an instance weight other than
+// 1 is used with a liblinear version without instance weight support.
+var ErrWeightsUnsupported = errors.New("Instance weights require liblinear with instance weight support")
+
+// ErrUnsupported is returned when a parameter is not supported by the
+// linked liblinear version.
+var ErrUnsupported = errors.New("Parameter not supported by the linked liblinear version")
+
+// ErrNotBinary is returned when a method that requires a model with
+// two classes is used with another model.
+var ErrNotBinary = errors.New("The model does not have exactly two classes")
+
+// ErrNoProbabilities is returned when probability estimates are
+// requested from a model that does not provide them.
+var ErrNoProbabilities = errors.New("The model does not provide probability estimates")
+
+// ErrNoLabelDictionary is returned when string labels are requested from
+// a model that does not have a label dictionary.
+var ErrNoLabelDictionary = errors.New("The model does not have a label dictionary")
+
+// ErrNoFeatureDictionary is returned when feature names are requested
+// from a model that does not have a feature dictionary.
+var ErrNoFeatureDictionary = errors.New("The model does not have a feature dictionary")
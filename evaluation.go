@@ -0,0 +1,204 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ClassScores contains the evaluation scores of a single class.
+type ClassScores struct {
+	Label     int
+	Precision float64
+	Recall    float64
+	F1        float64
+	// The number of instances with this label in the gold standard.
+	Support int
+}
+
+// A ClassificationEvaluation contains the scores of a classifier.
+type ClassificationEvaluation struct {
+	// The fraction of correctly classified instances.
+	Accuracy float64
+
+	// The scores of each class, ordered by label.
+	Classes []ClassScores
+
+	// Unweighted averages of the per-class scores.
+	MacroPrecision float64
+	MacroRecall    float64
+	MacroF1        float64
+
+	// Scores computed from the pooled counts of all classes.
+	MicroPrecision float64
+	MicroRecall    float64
+	MicroF1        float64
+
+	// The labels of the rows and columns of the confusion matrix, sorted
+	// in ascending order.
+	Labels []int
+	// ConfusionMatrix[i][j] is the number of instances with gold label
+	// Labels[i] that were classified as Labels[j].
+	ConfusionMatrix [][]int
+}
+
+// A RegressionEvaluation contains the scores of a regression model.
+type RegressionEvaluation struct {
+	MeanSquaredError  float64
+	MeanAbsoluteError float64
+	// The squared correlation coefficient, as reported by liblinear.
+	SquaredCorrelation float64
+}
+
+// EvaluateClassification compares predicted labels, such as those
+// returned by CrossValidation, against the labels of the instances in
+// the problem.
+func EvaluateClassification(problem *Problem, predicted []float64) (ClassificationEvaluation, error) {
+	if err := problem.checkOpen(); err != nil {
+		return ClassificationEvaluation{}, err
+	}
+
+	gold := problem.targets()
+	if err := checkPredictions(gold, predicted); err != nil {
+		return ClassificationEvaluation{}, err
+	}
+
+	// Collect the labels of the gold standard and the predictions.
+	labelSet := make(map[int]bool)
+	for i := range gold {
+		labelSet[int(gold[i])] = true
+		labelSet[int(predicted[i])] = true
+	}
+
+	labels := make([]int, 0, len(labelSet))
+	for label := range labelSet {
+		labels = append(labels, label)
+	}
+	sort.Ints(labels)
+
+	labelIdx := make(map[int]int)
+	for idx, label := range labels {
+		labelIdx[label] = idx
+	}
+
+	confusion := make([][]int, len(labels))
+	for i := range confusion {
+		confusion[i] = make([]int, len(labels))
+	}
+
+	correct := 0
+	for i := range gold {
+		g, p := int(gold[i]), int(predicted[i])
+		confusion[labelIdx[g]][labelIdx[p]]++
+		if g == p {
+			correct++
+		}
+	}
+
+	eval := ClassificationEvaluation{
+		Accuracy:        float64(correct) / float64(len(gold)),
+		Labels:          labels,
+		ConfusionMatrix: confusion,
+	}
+
+	var tpSum, goldSum, predictedSum int
+	for idx, label := range labels {
+		tp := confusion[idx][idx]
+		nGold, nPredicted := 0, 0
+		for j := range labels {
+			nGold += confusion[idx][j]
+			nPredicted += confusion[j][idx]
+		}
+
+		scores := ClassScores{
+			Label:     label,
+			Precision: ratio(tp, nPredicted),
+			Recall:    ratio(tp, nGold),
+			Support:   nGold,
+		}
+		scores.F1 = fScore(scores.Precision, scores.Recall)
+		eval.Classes = append(eval.Classes, scores)
+
+		eval.MacroPrecision += scores.Precision / float64(len(labels))
+		eval.MacroRecall += scores.Recall / float64(len(labels))
+		eval.MacroF1 += scores.F1 / float64(len(labels))
+
+		tpSum += tp
+		goldSum += nGold
+		predictedSum += nPredicted
+	}
+
+	eval.MicroPrecision = ratio(tpSum, predictedSum)
+	eval.MicroRecall = ratio(tpSum, goldSum)
+	eval.MicroF1 = fScore(eval.MicroPrecision, eval.MicroRecall)
+
+	return eval, nil
+}
+
+// EvaluateRegression compares predicted values, such as those returned
+// by CrossValidation, against the target values of the instances in the
+// problem. The scores are computed as in liblinear's train -v.
+func EvaluateRegression(problem *Problem, predicted []float64) (RegressionEvaluation, error) {
+	if err := problem.checkOpen(); err != nil {
+		return RegressionEvaluation{}, err
+	}
+
+	gold := problem.targets()
+	if err := checkPredictions(gold, predicted); err != nil {
+		return RegressionEvaluation{}, err
+	}
+
+	var totalError, totalAbsError, sumv, sumy, sumvv, sumyy, sumvy float64
+	for i := range gold {
+		y, v := gold[i], predicted[i]
+		totalError += (v - y) * (v - y)
+		totalAbsError += math.Abs(v - y)
+		sumv += v
+		sumy += y
+		sumvv += v * v
+		sumyy += y * y
+		sumvy += v * y
+	}
+
+	l := float64(len(gold))
+
+	return RegressionEvaluation{
+		MeanSquaredError:  totalError / l,
+		MeanAbsoluteError: totalAbsError / l,
+		SquaredCorrelation: ((l*sumvy - sumv*sumy) * (l*sumvy - sumv*sumy)) /
+			((l*sumvv - sumv*sumv) * (l*sumyy - sumy*sumy)),
+	}, nil
+}
+
+func checkPredictions(gold, predicted []float64) error {
+	if len(gold) == 0 {
+		return fmt.Errorf("Cannot evaluate an empty problem")
+	}
+
+	if len(gold) != len(predicted) {
+		return fmt.Errorf("Number of predictions (%d) does not match the number of instances (%d)",
+			len(predicted), len(gold))
+	}
+
+	return nil
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(d)
+}
+
+func fScore(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+
+	return 2 * precision * recall / (precision + recall)
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"math"
+	"testing"
+)
+
+func labelProblem(labels []float64) *Problem {
+	problem := NewProblem()
+	for _, label := range labels {
+		problem.Add(TrainingInstance{Label: label, Features: FeatureVector{{1, 1}}})
+	}
+	return problem
+}
+
+func checkScore(t *testing.T, name string, score, check float64) {
+	if math.Abs(score-check) > 1e-12 {
+		t.Errorf("%s = %f, want %f", name, score, check)
+	}
+}
+
+func TestEvaluateClassification(t *testing.T) {
+	problem := labelProblem([]float64{0, 0, 1, 1, 2})
+
+	eval, err := EvaluateClassification(problem, []float64{0, 1, 1, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkScore(t, "Accuracy", eval.Accuracy, 0.6)
+
+	check := []ClassScores{
+		{0, 0.5, 0.5, 0.5, 2},
+		{1, 2. / 3., 1, 0.8, 2},
+		{2, 0, 0, 0, 1},
+	}
+
+	if len(eval.Classes) != len(check) {
+		t.Fatalf("len(Classes) = %d, want %d", len(eval.Classes), len(check))
+	}
+
+	for i, c := range check {
+		scores := eval.Classes[i]
+		if scores.Label != c.Label || scores.Support != c.Support {
+			t.Errorf("Classes[%d] = (%d, %d), want (%d, %d)", i, scores.Label,
+				scores.Support, c.Label, c.Support)
+		}
+		checkScore(t, "Precision", scores.Precision, c.Precision)
+		checkScore(t, "Recall", scores.Recall, c.Recall)
+		checkScore(t, "F1", scores.F1, c.F1)
+	}
+
+	checkScore(t, "MacroPrecision", eval.MacroPrecision, (0.5+2./3.)/3)
+	checkScore(t, "MacroRecall", eval.MacroRecall, 0.5)
+	checkScore(t, "MacroF1", eval.MacroF1, 1.3/3)
+	checkScore(t, "MicroPrecision", eval.MicroPrecision, 0.6)
+	checkScore(t, "MicroRecall", eval.MicroRecall, 0.6)
+	checkScore(t, "MicroF1", eval.MicroF1, 0.6)
+
+	confusion := [][]int{{1, 1, 0}, {0, 2, 0}, {1, 0, 0}}
+	for i := range confusion {
+		for j := range confusion[i] {
+			if eval.ConfusionMatrix[i][j] != confusion[i][j] {
+				t.Errorf("ConfusionMatrix[%d][%d] = %d, want %d", i, j,
+					eval.ConfusionMatrix[i][j], confusion[i][j])
+			}
+		}
+	}
+}
+
+func TestEvaluateRegression(t *testing.T) {
+	problem := labelProblem([]float64{1, 2, 3})
+
+	eval, err := EvaluateRegression(problem, []float64{1, 2, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkScore(t, "MeanSquaredError", eval.MeanSquaredError, 1./3.)
+	checkScore(t, "MeanAbsoluteError", eval.MeanAbsoluteError, 1./3.)
+	checkScore(t, "SquaredCorrelation", eval.SquaredCorrelation, 81./84.)
+}
+
+func TestEvaluateLengthMismatch(t *testing.T) {
+	problem := labelProblem([]float64{1, 2, 3})
+
+	if _, err := EvaluateClassification(problem, []float64{1, 2}); err == nil {
+		t.Error("Predictions of the wrong length should be rejected")
+	}
+
+	if _, err := EvaluateRegression(problem, nil); err == nil {
+		t.Error("Predictions of the wrong length should be rejected")
+	}
+}
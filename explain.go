@@ -0,0 +1,151 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// FeatureContribution is the contribution of a feature to the decision
+// value of a label.
+type FeatureContribution struct {
+	Index int
+	// The name of the feature, if the model has a feature dictionary.
+	Name  string
+	Value float64
+	// The weight of the feature for the label.
+	Weight float64
+	// Weight * Value.
+	Contribution float64
+}
+
+// An Explanation breaks down the decision value of a label into the
+// contributions of the features of an instance.
+type Explanation struct {
+	// The explained label. For regression models, the label is zero.
+	Label int
+
+	// The decision value of the label, the sum of the bias and the
+	// feature contributions.
+	DecisionValue float64
+
+	// The contribution of the bias term. For one-class SVMs, the offset
+	// returned by Model.Rho() is included.
+	Bias float64
+
+	// The contributions of the features, sorted by decreasing magnitude.
+	// Features that were not seen during training do not contribute and
+	// are omitted.
+	Contributions []FeatureContribution
+}
+
+// Explain explains the prediction of an instance, by breaking down the
+// decision value of the predicted label into feature contributions. For
+// regression models, the predicted value is explained.
+func (model *Model) Explain(nodes []FeatureValue) (Explanation, error) {
+	m := model.model
+	if m == nil {
+		return Explanation{}, ErrClosed
+	}
+
+	if m.isRegression() {
+		return m.explain(nodes, 0, 0, 1, model.featureDict), nil
+	}
+
+	values := make([]float64, m.nrClass)
+	label := int(m.predictValues(nodes, values))
+
+	return model.ExplainLabel(nodes, label)
+}
+
+// ExplainLabel breaks down the decision value of the given label for an
+// instance into feature contributions. For two-class models with a single
+// weight vector, the decision value of the second label is the negated
+// decision value of the first label.
+func (model *Model) ExplainLabel(nodes []FeatureValue, label int) (Explanation, error) {
+	m := model.model
+	if m == nil {
+		return Explanation{}, ErrClosed
+	}
+
+	if m.isRegression() {
+		return Explanation{}, fmt.Errorf("Cannot explain label %d of a regression model", label)
+	}
+
+	labels := model.Labels()
+	class := -1
+	for i, l := range labels {
+		if l == label {
+			class = i
+			break
+		}
+	}
+
+	if class == -1 {
+		return Explanation{}, fmt.Errorf("Model does not have label: %d", label)
+	}
+
+	// Two-class models with a single weight vector: the second label uses
+	// the negated weights of the first label.
+	sign := 1.0
+	if m.nrWeightVectors() == 1 && class == 1 {
+		class = 0
+		sign = -1
+	}
+
+	return m.explain(nodes, label, class, sign, model.featureDict), nil
+}
+
+// explain computes the contributions to the decision value of the weight
+// vector class, multiplied by sign. If names is not nil, it is used to
+// look up feature names.
+func (m *linearModel) explain(nodes []FeatureValue, label, class int, sign float64, names *FeatureDictionary) Explanation {
+	nWeights := m.nrWeightVectors()
+
+	e := Explanation{Label: label}
+
+	for _, node := range nodes {
+		// Features that were not seen during training are ignored.
+		if node.Index < 1 || node.Index > m.nrFeature {
+			continue
+		}
+
+		var name string
+		if names != nil {
+			name, _ = names.Name(node.Index)
+		}
+
+		w := sign * m.w[(node.Index-1)*nWeights+class]
+		e.Contributions = append(e.Contributions, FeatureContribution{
+			Index:        node.Index,
+			Name:         name,
+			Value:        node.Value,
+			Weight:       w,
+			Contribution: w * node.Value,
+		})
+	}
+
+	if m.bias >= 0 {
+		e.Bias = m.w[m.nrFeature*nWeights+class] * m.bias
+	}
+	if m.isOneClass() {
+		e.Bias -= m.rho
+	}
+	e.Bias *= sign
+
+	// Sum before sorting, in the order of the instance.
+	for _, c := range e.Contributions {
+		e.DecisionValue += c.Contribution
+	}
+	e.DecisionValue += e.Bias
+
+	sort.SliceStable(e.Contributions, func(i, j int) bool {
+		return math.Abs(e.Contributions[i].Contribution) > math.Abs(e.Contributions[j].Contribution)
+	})
+
+	return e
+}
@@ -0,0 +1,243 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// A FoldFunc returns the fold of the instance with the given index.
+type FoldFunc func(idx int, instance *TrainingInstance) int
+
+// CrossValidationFolds performs cross-validation using the given
+// assignment of instances to folds, where folds[i] is the fold of
+// instance i. Folds are numbered from zero. Each fold is evaluated using
+// the model trained with the remaining folds. The slice that is returned
+// contains the predicted instance classes.
+//
+// In contrast to CrossValidation, the folds are under the control of the
+// caller. Folds can be constructed using RandomFolds, StratifiedFolds,
+// GroupedFolds, or AssignFolds.
+func CrossValidationFolds(problem *Problem, param Parameters, folds []int) ([]float64, error) {
+	return crossValidationFolds(context.Background(), problem, param, folds)
+}
+
+func crossValidationFolds(ctx context.Context, problem *Problem, param Parameters, folds []int) ([]float64, error) {
+	predictions := make([]float64, len(folds))
+	err := forEachFold(ctx, problem, param, folds, func(fold int, model *Model, instances []FeatureVector, evalIndices []int) error {
+		for _, idx := range evalIndices {
+			predictions[idx] = model.Predict(instances[idx])
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return predictions, nil
+}
+
+// forEachFold trains a model for each fold on the remaining folds, where
+// folds[i] is the fold of instance i. fun is called with the model, the
+// feature vectors of all instances, and the indices of the instances in
+// the fold. Folds are processed in ascending order. The context is
+// checked before training the model of a fold.
+func forEachFold(ctx context.Context, problem *Problem, param Parameters, folds []int,
+	fun func(fold int, model *Model, instances []FeatureVector, evalIndices []int) error) error {
+	if err := problem.checkOpen(); err != nil {
+		return err
+	}
+
+	var instances []FeatureVector
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		instances = append(instances, instance.Features)
+		return true
+	})
+
+	if len(folds) != len(instances) {
+		return fmt.Errorf("Number of folds (%d) does not match the number of instances (%d)",
+			len(folds), len(instances))
+	}
+
+	foldInstances := make(map[int][]int)
+	for idx, fold := range folds {
+		if fold < 0 {
+			return fmt.Errorf("Instance %d has a negative fold: %d", idx, fold)
+		}
+		foldInstances[fold] = append(foldInstances[fold], idx)
+	}
+
+	if len(foldInstances) < 2 {
+		return errors.New("Cross-validation requires at least two non-empty folds")
+	}
+
+	// Evaluate the folds in a fixed order.
+	foldIds := make([]int, 0, len(foldInstances))
+	for fold := range foldInstances {
+		foldIds = append(foldIds, fold)
+	}
+	sort.Ints(foldIds)
+
+	for _, fold := range foldIds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var trainIndices []int
+		for idx, instanceFold := range folds {
+			if instanceFold != fold {
+				trainIndices = append(trainIndices, idx)
+			}
+		}
+
+		train, err := problem.subset(trainIndices)
+		if err != nil {
+			return err
+		}
+
+		model, err := TrainModel(param, train)
+		train.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := fun(fold, model, instances, foldInstances[fold]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AssignFolds assigns the instances of a problem to folds using a
+// function.
+func AssignFolds(problem *Problem, fun FoldFunc) []int {
+	var folds []int
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		folds = append(folds, fun(len(folds), instance))
+		return true
+	})
+
+	return folds
+}
+
+// RandomFolds randomly assigns the instances of a problem to nFolds folds
+// of (nearly) equal size. The same seed always results in the same folds.
+func RandomFolds(problem *Problem, nFolds uint, seed int64) ([]int, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if nFolds < 1 {
+		return nil, errors.New("The number of folds should be at least one")
+	}
+
+	n := len(problem.targets())
+	rng := rand.New(rand.NewSource(seed))
+
+	folds := make([]int, n)
+	for i, idx := range rng.Perm(n) {
+		folds[idx] = i % int(nFolds)
+	}
+
+	return folds, nil
+}
+
+// StratifiedFolds randomly assigns the instances of a problem to nFolds
+// folds, such that the label distribution of each fold is (nearly) equal
+// to that of the problem. The same seed always results in the same folds.
+func StratifiedFolds(problem *Problem, nFolds uint, seed int64) ([]int, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if nFolds < 1 {
+		return nil, errors.New("The number of folds should be at least one")
+	}
+
+	targets := problem.targets()
+	rng := rand.New(rand.NewSource(seed))
+
+	// Group the instances by label, in order of first occurrence.
+	var labels []float64
+	labelInstances := make(map[float64][]int)
+	for idx, label := range targets {
+		if _, ok := labelInstances[label]; !ok {
+			labels = append(labels, label)
+		}
+		labelInstances[label] = append(labelInstances[label], idx)
+	}
+
+	// Deal the shuffled instances of each label over the folds. The next
+	// label continues at the fold where the previous label ended, to keep
+	// the folds balanced in size.
+	folds := make([]int, len(targets))
+	fold := 0
+	for _, label := range labels {
+		instances := labelInstances[label]
+		for _, i := range rng.Perm(len(instances)) {
+			folds[instances[i]] = fold
+			fold = (fold + 1) % int(nFolds)
+		}
+	}
+
+	return folds, nil
+}
+
+// GroupedFolds assigns groups of instances to nFolds folds, such that
+// all instances of a group are in the same fold. groups[i] is the group
+// of instance i. Larger groups are assigned first, each group is assigned
+// to the fold with the fewest instances. The seed determines the order of
+// groups of the same size.
+func GroupedFolds(groups []int, nFolds uint, seed int64) ([]int, error) {
+	if nFolds < 1 {
+		return nil, errors.New("The number of folds should be at least one")
+	}
+
+	var groupIds []int
+	groupSizes := make(map[int]int)
+	for _, group := range groups {
+		if _, ok := groupSizes[group]; !ok {
+			groupIds = append(groupIds, group)
+		}
+		groupSizes[group]++
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	shuffled := make([]int, len(groupIds))
+	for i, j := range rng.Perm(len(groupIds)) {
+		shuffled[i] = groupIds[j]
+	}
+
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return groupSizes[shuffled[i]] > groupSizes[shuffled[j]]
+	})
+
+	foldSizes := make([]int, nFolds)
+	groupFolds := make(map[int]int)
+	for _, group := range shuffled {
+		smallest := 0
+		for fold, size := range foldSizes {
+			if size < foldSizes[smallest] {
+				smallest = fold
+			}
+		}
+
+		groupFolds[group] = smallest
+		foldSizes[smallest] += groupSizes[group]
+	}
+
+	folds := make([]int, len(groups))
+	for idx, group := range groups {
+		folds[idx] = groupFolds[group]
+	}
+
+	return folds, nil
+}
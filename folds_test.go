@@ -0,0 +1,136 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import "testing"
+
+func TestCrossValidationFolds(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	folds, err := StratifiedFolds(problem, 5, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := CrossValidationFolds(problem, DefaultParameters(), folds)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatalf("Could not train model: %s", err.Error())
+	}
+
+	correctResults := []float64{0, 0, 0, 0, 0, 1, 1, 1, 1, 1}
+	for idx, class := range correctResults {
+		if results[idx] != class {
+			t.Errorf("class(%d) = %f, want class(%d) = %f", idx, results[idx], idx, class)
+		}
+	}
+}
+
+func TestCrossValidationFoldsInvalid(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	invalid := [][]int{
+		{0, 1, 0, 1},
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		{0, 1, 0, 1, 0, 1, 0, 1, 0, -1},
+	}
+
+	for _, folds := range invalid {
+		if _, err := CrossValidationFolds(problem, DefaultParameters(), folds); err == nil {
+			t.Errorf("Invalid folds should be rejected: %v", folds)
+		}
+	}
+}
+
+func TestRandomFolds(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	folds, err := RandomFolds(problem, 3, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := make([]int, 3)
+	for _, fold := range folds {
+		sizes[fold]++
+	}
+
+	for fold, size := range sizes {
+		if size < 3 || size > 4 {
+			t.Errorf("size(%d) = %d, want 3 or 4", fold, size)
+		}
+	}
+
+	again, _ := RandomFolds(problem, 3, 42)
+	for idx := range folds {
+		if folds[idx] != again[idx] {
+			t.Fatal("Folds differ when using the same seed")
+		}
+	}
+}
+
+func TestStratifiedFolds(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	folds, err := StratifiedFolds(problem, 5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each fold should have one instance of each class.
+	counts := make(map[[2]int]int)
+	for idx, label := range problem.targets() {
+		counts[[2]int{folds[idx], int(label)}]++
+	}
+
+	for fold := 0; fold < 5; fold++ {
+		for label := 0; label < 2; label++ {
+			if n := counts[[2]int{fold, label}]; n != 1 {
+				t.Errorf("count(%d, %d) = %d, want 1", fold, label, n)
+			}
+		}
+	}
+}
+
+func TestGroupedFolds(t *testing.T) {
+	groups := []int{7, 7, 7, 3, 3, 5, 5, 9, 1, 1}
+
+	folds, err := GroupedFolds(groups, 3, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groupFolds := make(map[int]int)
+	sizes := make([]int, 3)
+	for idx, group := range groups {
+		if fold, ok := groupFolds[group]; ok && fold != folds[idx] {
+			t.Errorf("Group %d is split over folds %d and %d", group, fold, folds[idx])
+		}
+		groupFolds[group] = folds[idx]
+		sizes[folds[idx]]++
+	}
+
+	for fold, size := range sizes {
+		if size < 3 || size > 4 {
+			t.Errorf("size(%d) = %d, want 3 or 4", fold, size)
+		}
+	}
+}
+
+func TestAssignFolds(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	folds := AssignFolds(problem, func(idx int, instance *TrainingInstance) int {
+		return idx % 2
+	})
+
+	for idx, fold := range folds {
+		if fold != idx%2 {
+			t.Errorf("fold(%d) = %d, want %d", idx, fold, idx%2)
+		}
+	}
+}
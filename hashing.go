@@ -0,0 +1,141 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// MaxHashBits is the maximum number of bits of a feature hasher.
+const MaxHashBits = 30
+
+// A FeatureHasher maps feature names to indices in 1..2^bits by hashing
+// the names. In contrast to a FeatureDictionary, a hasher does not need
+// to store the names of the features, so its size does not depend on the
+// vocabulary. Different names can be mapped to the same index. To reduce
+// the bias that is caused by such collisions, the hash also determines
+// the sign of the feature value, such that colliding values tend to
+// cancel each other out.
+//
+// The same hasher (number of bits and seed) must be used for training
+// and prediction. Models get the hasher of the problem they were trained
+// on, and the hasher is saved with the model.
+type FeatureHasher struct {
+	bits uint
+	seed uint32
+}
+
+// NewFeatureHasher creates a feature hasher that maps features to
+// indices in 1..2^bits. Different seeds give different mappings.
+func NewFeatureHasher(bits uint, seed uint32) (*FeatureHasher, error) {
+	if bits < 1 || bits > MaxHashBits {
+		return nil, fmt.Errorf("The number of bits should be in 1..%d: %d", MaxHashBits, bits)
+	}
+
+	return &FeatureHasher{bits, seed}, nil
+}
+
+// Bits returns the number of bits of the hasher.
+func (h *FeatureHasher) Bits() uint {
+	return h.bits
+}
+
+// Seed returns the seed of the hasher.
+func (h *FeatureHasher) Seed() uint32 {
+	return h.seed
+}
+
+// Index returns the index of a feature and the sign (1 or -1) that
+// should be applied to its value.
+func (h *FeatureHasher) Index(name string) (index int, sign float64) {
+	hash := fnv.New64a()
+
+	var seed [4]byte
+	binary.LittleEndian.PutUint32(seed[:], h.seed)
+	hash.Write(seed[:])
+	hash.Write([]byte(name))
+
+	sum := hash.Sum64()
+
+	// The lower bits give the index, the highest bit the sign.
+	index = int(sum&(1<<h.bits-1)) + 1
+	if sum>>63 == 1 {
+		return index, -1
+	}
+
+	return index, 1
+}
+
+// Vector converts named feature values to a feature vector that is
+// sorted by index. The values of features that are mapped to the same
+// index are summed.
+func (h *FeatureHasher) Vector(features map[string]float64) FeatureVector {
+	values := make(map[int]float64)
+	for name, value := range features {
+		index, sign := h.Index(name)
+		values[index] += sign * value
+	}
+
+	return hashedVector(values)
+}
+
+// BinaryVector converts feature names to a feature vector that is sorted
+// by index, where each feature has the value one. Repeated names are
+// counted.
+func (h *FeatureHasher) BinaryVector(names []string) FeatureVector {
+	values := make(map[int]float64)
+	for _, name := range names {
+		index, sign := h.Index(name)
+		values[index] += sign
+	}
+
+	return hashedVector(values)
+}
+
+// hashedVector converts index-value pairs to a sorted feature vector.
+// Values that cancelled each other out are omitted.
+func hashedVector(values map[int]float64) FeatureVector {
+	fv := make(FeatureVector, 0, len(values))
+	for index, value := range values {
+		if value != 0 {
+			fv = append(fv, FeatureValue{index, value})
+		}
+	}
+
+	sort.Slice(fv, func(i, j int) bool {
+		return fv[i].Index < fv[j].Index
+	})
+
+	return fv
+}
+
+// FeatureHasher returns the feature hasher of the problem, or nil if the
+// problem does not have a feature hasher.
+func (problem *Problem) FeatureHasher() *FeatureHasher {
+	return problem.hasher
+}
+
+// SetFeatureHasher sets the feature hasher of the problem. The hasher is
+// not used by the problem itself: feature vectors are constructed using
+// FeatureHasher.Vector. Models that are trained on the problem get the
+// hasher.
+func (problem *Problem) SetFeatureHasher(h *FeatureHasher) {
+	problem.hasher = h
+}
+
+// FeatureHasher returns the feature hasher of the model, or nil if the
+// model does not have a feature hasher.
+func (model *Model) FeatureHasher() *FeatureHasher {
+	return model.hasher
+}
+
+// SetFeatureHasher sets the feature hasher of the model. The hasher is
+// saved with the model.
+func (model *Model) SetFeatureHasher(h *FeatureHasher) {
+	model.hasher = h
+}
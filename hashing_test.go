@@ -0,0 +1,119 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFeatureHasher(t *testing.T) {
+	h, err := NewFeatureHasher(4, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signs := make(map[float64]bool)
+	for _, name := range []string{"a", "beautiful", "album", "crappy", "ugly", "book", "the", "of"} {
+		index, sign := h.Index(name)
+		if index < 1 || index > 16 {
+			t.Errorf("Index(%s) = %d, should be in 1..16", name, index)
+		}
+		signs[sign] = true
+
+		// Hashing is deterministic.
+		if index2, sign2 := h.Index(name); index2 != index || sign2 != sign {
+			t.Errorf("Index(%s) is not deterministic", name)
+		}
+	}
+
+	if !signs[1] || !signs[-1] {
+		t.Errorf("Expected both signs, got: %v", signs)
+	}
+
+	// Another seed should give another mapping.
+	h2, _ := NewFeatureHasher(20, 43)
+	h1, _ := NewFeatureHasher(20, 42)
+	same := 0
+	for _, name := range []string{"a", "beautiful", "album", "crappy", "ugly"} {
+		i1, _ := h1.Index(name)
+		i2, _ := h2.Index(name)
+		if i1 == i2 {
+			same++
+		}
+	}
+	if same == 5 {
+		t.Error("Different seeds give the same indices")
+	}
+
+	for _, bits := range []uint{0, MaxHashBits + 1} {
+		if _, err := NewFeatureHasher(bits, 0); err == nil {
+			t.Errorf("NewFeatureHasher(%d) should fail", bits)
+		}
+	}
+}
+
+func TestFeatureHasherVector(t *testing.T) {
+	h, _ := NewFeatureHasher(1, 7)
+
+	// With two indices, collisions are guaranteed.
+	names := []string{"a", "b", "c", "d", "e"}
+	expected := make(map[int]float64)
+	for _, name := range names {
+		index, sign := h.Index(name)
+		expected[index] += sign
+	}
+
+	fv := h.BinaryVector(names)
+	for i, fval := range fv {
+		if i > 0 && fv[i-1].Index >= fval.Index {
+			t.Errorf("BinaryVector() is not sorted: %v", fv)
+		}
+		if fval.Value != expected[fval.Index] || fval.Value == 0 {
+			t.Errorf("BinaryVector()[%d] = %v, want value %f", i, fval, expected[fval.Index])
+		}
+	}
+
+	index, sign := h.Index("a")
+	fv = h.Vector(map[string]float64{"a": 2.5})
+	if len(fv) != 1 || fv[0] != (FeatureValue{index, sign * 2.5}) {
+		t.Errorf("Vector() = %v, want [{%d %f}]", fv, index, sign*2.5)
+	}
+}
+
+func TestFeatureHasherModel(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	h, _ := NewFeatureHasher(1, 4000000000)
+	model.SetFeatureHasher(h)
+
+	var buf bytes.Buffer
+	if _, err := model.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := readTestModel(t, buf.String()).FeatureHasher()
+	if h2 == nil || h2.Bits() != 1 || h2.Seed() != 4000000000 {
+		t.Errorf("FeatureHasher() = %v, want bits 1, seed 4000000000", h2)
+	}
+}
+
+func TestFeatureHasherTrain(t *testing.T) {
+	h, _ := NewFeatureHasher(16, 0)
+	problem := NewProblem()
+	problem.SetFeatureHasher(h)
+	problem.Add(TrainingInstance{Label: 0, Features: h.BinaryVector([]string{"a", "beautiful", "album"})})
+	problem.Add(TrainingInstance{Label: 1, Features: h.BinaryVector([]string{"a", "crappy", "ugly", "album"})})
+
+	model := trainModel(t, DefaultParameters(), problem)
+
+	if model.FeatureHasher() != h {
+		t.Error("Model should have the hasher of the problem")
+	}
+
+	if label := model.Predict(h.BinaryVector([]string{"beautiful", "book"})); label != 0 {
+		t.Errorf("Predict() = %f, want 0", label)
+	}
+}
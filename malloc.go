@@ -17,58 +17,59 @@ import (
 
 type mallocFunc func() unsafe.Pointer
 
-// Mallocs, garbage-collects on fail, mallocs, panics on fail.
-func tryNew(malloc mallocFunc) unsafe.Pointer {
+// Mallocs, garbage-collects on fail, mallocs, returns ErrOutOfMemory on
+// fail.
+func tryNew(malloc mallocFunc) (unsafe.Pointer, error) {
 	p := malloc()
 	if p == nil {
 		// Garbage-collect and try again.
 		runtime.GC()
 		p = malloc()
 		if p == nil {
-			panic("not enough memory")
+			return nil, ErrOutOfMemory
 		}
 	}
-	return p
+	return p, nil
 }
 
-func newLabels(n C.int) *C.int {
-	labels := tryNew(func() unsafe.Pointer {
+func newLabels(n C.int) (*C.int, error) {
+	labels, err := tryNew(func() unsafe.Pointer {
 		return unsafe.Pointer(C.labels_new(n))
 	})
-	return (*C.int)(labels)
+	return (*C.int)(labels), err
 }
 
-func newProbs(model *C.model_t) *C.double {
-	probs := tryNew(func() unsafe.Pointer {
-		return unsafe.Pointer(C.probs_new(model))
-	})
-	return (*C.double)(probs)
-}
-
-func newDouble(n C.size_t) *C.double {
-	p := tryNew(func() unsafe.Pointer {
+func newDouble(n C.size_t) (*C.double, error) {
+	p, err := tryNew(func() unsafe.Pointer {
 		return unsafe.Pointer(C.double_new(n))
 	})
-	return (*C.double)(p)
+	return (*C.double)(p), err
 }
 
-func newParameter() *C.parameter_t {
-	param := tryNew(func() unsafe.Pointer {
+func newParameter() (*C.parameter_t, error) {
+	param, err := tryNew(func() unsafe.Pointer {
 		return unsafe.Pointer(C.parameter_new())
 	})
-	return (*C.parameter_t)(param)
+	return (*C.parameter_t)(param), err
 }
 
-func newProblem() *C.problem_t {
-	problem := tryNew(func() unsafe.Pointer {
+func newProblem() (*C.problem_t, error) {
+	problem, err := tryNew(func() unsafe.Pointer {
 		return unsafe.Pointer(C.problem_new())
 	})
-	return (*C.problem_t)(problem)
+	return (*C.problem_t)(problem), err
 }
 
-func newNodes(n C.size_t) *C.feature_node_t {
-	nodes := tryNew(func() unsafe.Pointer {
+func newNodes(n C.size_t) (*C.feature_node_t, error) {
+	nodes, err := tryNew(func() unsafe.Pointer {
 		return unsafe.Pointer(C.nodes_new(n))
 	})
-	return (*C.feature_node_t)(nodes)
+	return (*C.feature_node_t)(nodes), err
+}
+
+func newBiasedProblem(problem *C.problem_t) (*C.problem_t, error) {
+	biased, err := tryNew(func() unsafe.Pointer {
+		return unsafe.Pointer(C.problem_with_bias(problem))
+	})
+	return (*C.problem_t)(biased), err
 }
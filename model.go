@@ -4,135 +4,217 @@
 
 package golinear
 
-/*
-#include <stdlib.h>
-#include "wrap.h"
-*/
-import "C"
-
 import (
+	"bufio"
 	"errors"
 	"fmt"
-	"runtime"
-	"unsafe"
+	"io"
+	"math"
+	"os"
 )
 
 // A Model contains the trained model and can be used to predict the
 // class of a seen or unseen instance.
 type Model struct {
-	model *C.model_t
-	// Keep a pointer to the problem, since C model depends on it.
-	problem *Problem
-	// Computing the labels for each classification gets a bit
-	// expensive, cache the labels when they are used.
-	labelCache []int
+	model *linearModel
+	// The number of goroutines used for batch prediction.
+	nThreads int
+	encoders
 }
 
-// Weights extracts the weight vector of a two-class problem.
-func (model *Model) Weights() []float64 {
-	if model.model.nr_class != 2 {
-		panic(fmt.Sprint("not exactly two classes: ", model.model.nr_class))
+// Weights extracts the weight vector of a two-class problem. A positive
+// decision value favors the first label returned by Labels(). If the
+// model does not have two classes, ErrNotBinary is returned.
+func (model *Model) Weights() ([]float64, error) {
+	weights, _, err := model.WeightsMulti()
+	if err != nil {
+		return nil, err
 	}
-	n := model.model.nr_feature
-	weights := make([]float64, n)
-	for i := range weights {
-		weights[i] = float64(C.get_double_idx(model.model.w, C.int(i)))
+
+	m := model.model
+	if m.nrClass != 2 {
+		return nil, ErrNotBinary
+	}
+
+	// Crammer and Singer models store a weight vector per class, use the
+	// difference to obtain the decision function of the first class.
+	if m.nrWeightVectors() == 2 {
+		for i := range weights[0] {
+			weights[0][i] -= weights[1][i]
+		}
 	}
-	return weights
+
+	return weights[0], nil
 }
 
-// Bias extracts the bias of a two-class problem.
-func (model *Model) Bias() float64 {
-	if model.model.nr_class != 2 {
-		panic(fmt.Sprint("not exactly two classes: ", model.model.nr_class))
+// Bias extracts the bias of a two-class problem. The bias is zero if the
+// model was trained without a bias term. For one-class SVMs, the bias is
+// the negation of the offset returned by Rho(). If the model does not
+// have two classes, ErrNotBinary is returned.
+func (model *Model) Bias() (float64, error) {
+	_, biases, err := model.WeightsMulti()
+	if err != nil {
+		return 0, err
+	}
+
+	m := model.model
+	if m.nrClass != 2 {
+		return 0, ErrNotBinary
+	}
+
+	if m.nrWeightVectors() == 2 {
+		return biases[0] - biases[1], nil
 	}
-	// model.nr_feature does not include bias.
-	n := model.model.nr_feature
-	return float64(C.get_double_idx(model.model.w, n))
+
+	return biases[0], nil
 }
 
-// WeightsMulti extracts the weight vectors of a multi-class problem.
+// WeightsMulti extracts the weight vector and bias of each class of a
+// model. The weight vectors and biases are ordered as the labels returned
+// by Labels(). The biases are zero if the model was trained without a
+// bias term. For one-class SVMs, the offset returned by Rho() is
+// included in the bias.
 //
-// NOT IMPLEMENTED.
-func (model *Model) WeightsMulti() [][]float64 {
-	panic("not implemented")
-}
-
-// TrainModel trains an SVM using the given parameters and problem.
-func TrainModel(param Parameters, problem *Problem) (*Model, error) {
-	cParam := toCParameter(param)
-	defer func() {
-		C.parameter_free(cParam)
-		C.destroy_param_wrap(cParam)
-		C.free(unsafe.Pointer(cParam))
-	}()
-
-	// Check validity of the parameters.
-	r := C.check_parameter_wrap(problem.problem, cParam)
-	if r != nil {
-		msg := C.GoString(r)
-		return nil, errors.New(msg)
-	}
-
-	cmodel := C.train_wrap(problem.problem, cParam)
-	model := &Model{cmodel, problem, nil}
-	runtime.SetFinalizer(model, finalizeModel)
-	return model, nil
+// liblinear only stores a single weight vector for two-class problems
+// (unless the Crammer and Singer solver is used). In this case, the weight
+// vector and bias of the second class are the negation of those of the
+// first class.
+func (model *Model) WeightsMulti() ([][]float64, []float64, error) {
+	m := model.model
+	if m == nil {
+		return nil, nil, ErrClosed
+	}
+
+	nWeights := m.nrWeightVectors()
+
+	weights := make([][]float64, m.nrClass)
+	biases := make([]float64, m.nrClass)
+
+	// The weights are stored feature-major: the weights of feature i for
+	// all classes are stored at i * nWeights .. (i + 1) * nWeights - 1.
+	for class := 0; class < nWeights; class++ {
+		weights[class] = make([]float64, m.nrFeature)
+		for i := range weights[class] {
+			weights[class][i] = m.w[i*nWeights+class]
+		}
+
+		if m.bias >= 0 {
+			biases[class] = m.w[m.nrFeature*nWeights+class]
+		}
+	}
+
+	if m.isOneClass() {
+		biases[0] -= m.rho
+	}
+
+	if nWeights == 1 && m.nrClass == 2 {
+		weights[1] = make([]float64, m.nrFeature)
+		for i, w := range weights[0] {
+			weights[1][i] = -w
+		}
+		biases[1] = -biases[0]
+	}
+
+	return weights, biases, nil
 }
 
 // LoadModel loads a previously saved model.
 func LoadModel(filename string) (*Model, error) {
-	cFilename := C.CString(filename)
-	defer C.free(unsafe.Pointer(cFilename))
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
-	model := &Model{C.load_model_wrap(cFilename), nil, nil}
+	model, err := ReadModel(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read model %s: %s", filename, err.Error())
+	}
 
-	if model.model == nil {
-		return nil, errors.New("Cannot read model: " + filename)
+	return model, nil
+}
+
+// ReadModel reads a model in the liblinear model format from a reader.
+func ReadModel(r io.Reader) (*Model, error) {
+	s := newWordScanner(r, "model")
+
+	m, err := readLinearModel(s)
+	if err != nil {
+		return nil, err
 	}
 
-	runtime.SetFinalizer(model, finalizeModel)
+	e, err := readEncoders(s)
+	if err != nil {
+		return nil, err
+	}
+
+	model := &Model{model: m, encoders: e}
+	if e.calibrator != nil {
+		if err := e.calibrator.checkModel(model); err != nil {
+			return nil, err
+		}
+	}
 
 	return model, nil
 }
 
-// Labels returns a slice with class labels.
+// Labels returns a slice with class labels. For regression models, the
+// labels are zero. For one-class SVMs, the labels are 1 (inlier) and -1
+// (outlier). If the model is closed, the slice is empty.
 func (model *Model) Labels() []int {
-	if model.labelCache != nil {
-		labels := make([]int, len(model.labelCache))
-		copy(labels, model.labelCache)
-		return labels
+	m := model.model
+	if m == nil {
+		return nil
 	}
 
-	nClasses := C.get_nr_class_wrap(model.model)
-	cLabels := newLabels(nClasses)
-	defer C.free(unsafe.Pointer(cLabels))
-	C.get_labels_wrap(model.model, cLabels)
+	if m.isOneClass() {
+		return []int{1, -1}
+	}
 
-	labels := make([]int, int(nClasses))
+	labels := make([]int, m.nrClass)
+	copy(labels, m.labels)
+	return labels
+}
 
-	for idx := range labels {
-		labels[idx] = int(C.get_int_idx(cLabels, C.int(idx)))
+// Rho returns the offset of the decision function of a one-class SVM:
+// an instance x is an inlier if w·x - rho > 0. Rho is zero for other
+// models and closed models.
+func (model *Model) Rho() float64 {
+	if model.model == nil {
+		return 0
 	}
 
-	model.labelCache = make([]int, len(labels))
-	copy(model.labelCache, labels)
-
-	return labels
+	return model.model.rho
 }
 
-// Predict the label of an instance using the given model.
+// Predict the label of an instance using the given model. One-class SVMs
+// predict 1 for inliers and -1 for outliers. If the model is closed, NaN
+// is returned.
 func (model *Model) Predict(nodes []FeatureValue) float64 {
-	cn := cNodes(nodes)
-	defer C.nodes_free(cn)
-	return float64(C.predict_wrap(model.model, cn))
+	m := model.model
+	if m == nil {
+		return math.NaN()
+	}
+
+	values := make([]float64, m.nrClass)
+	return m.predictValues(nodes, values)
+}
+
+// SupportsProbability returns true if the model provides probability
+// estimates. Probability estimates are given by logistic regression
+// models and models with a calibrator. Closed models do not provide
+// probability estimates.
+func (model *Model) SupportsProbability() bool {
+	return model.model != nil && (model.calibrator != nil || model.model.isProbability())
 }
 
 // PredictProbability predict the label of an instance, given a model
 // with probability information. This method returns the label of the
 // predicted class and a map of class probabilities. Probability
-// estimates are currently given for logistic regression only. If another
-// solver is used, the probability of each class is zero.
+// estimates are given for logistic regression and for models with a
+// calibrator, see SetCalibrator. The calibrator is used if the model has
+// one. For other models, ErrNoProbabilities is returned, see
+// SupportsProbability.
 func (model *Model) PredictProbability(nodes []FeatureValue) (float64, map[int]float64, error) {
 	r, probs, err := model.PredictProbabilitySlice(nodes)
 	if err != nil {
@@ -151,30 +233,40 @@ func (model *Model) PredictProbability(nodes []FeatureValue) (float64, map[int]f
 // PredictProbabilitySlice predicts the label of an instance, given a
 // model with probability information. This method returns the label
 // of the predicted class and a slice of class probabilities. Probability
-// estimates are currently given for logistic regression only. If another
-// solver is used, the probability of each class is zero.
+// estimates are given for logistic regression and for models with a
+// calibrator, see SetCalibrator. The calibrator is used if the model has
+// one. For other models, ErrNoProbabilities is returned, see
+// SupportsProbability.
 //
 // The PredictProbability function is more user-friendly, but has the
 // overhead of constructing a map. If you are only interested in the
 // classes with the highest probabilities, it may be better to use
 // this function in conjunction with Labels().
 func (model *Model) PredictProbabilitySlice(nodes []FeatureValue) (float64, []float64, error) {
-	// Allocate sparse C feature vector.
-	cn := cNodes(nodes)
-	defer C.nodes_free(cn)
+	if model.model == nil {
+		return 0, nil, ErrClosed
+	}
+
+	if model.calibrator != nil {
+		return model.calibrator.PredictProbabilitySlice(model, nodes)
+	}
+
+	if !model.model.isProbability() {
+		return 0, nil, ErrNoProbabilities
+	}
 
-	probs := make([]float64, len(model.Labels()))
-	r := C.predict_probability_wrap(model.model, cn, (*C.double)(unsafe.Pointer(&probs[0])))
+	probs := make([]float64, model.model.nrClass)
+	r := model.model.predictProbability(nodes, probs)
 
-	return float64(r), probs, nil
+	return r, probs, nil
 }
 
 // PredictDecisionValues predicts the label of an instance. In contrast
 // to Predict, it also returns the per-label decision values.
 func (model *Model) PredictDecisionValues(nodes []FeatureValue) (float64, map[int]float64, error) {
-	r, values, err := model.PredictDecisionValues(nodes)
+	r, values, err := model.PredictDecisionValuesSlice(nodes)
 	if err != nil {
-		return r, values, err
+		return r, nil, err
 	}
 
 	// Store the decision values in a map
@@ -193,34 +285,62 @@ func (model *Model) PredictDecisionValues(nodes []FeatureValue) (float64, map[in
 // the classes with the highest decision values, it may be better to
 // use this function in conjunction with Labels().
 func (model *Model) PredictDecisionValuesSlice(nodes []FeatureValue) (float64, []float64, error) {
-	// Allocate sparse C feature vector.
-	cn := cNodes(nodes)
-	defer C.nodes_free(cn)
-
-	labels := model.Labels()
-
-	// Allocate C array for decision values.
-	values := make([]float64, len(labels))
+	if model.model == nil {
+		return 0, nil, ErrClosed
+	}
 
-	r := C.predict_values_wrap(model.model, cn, (*C.double)(unsafe.Pointer(&values[0])))
+	values := make([]float64, model.model.nrClass)
+	r := model.model.predictValues(nodes, values)
 
-	return float64(r), values, nil
+	return r, values, nil
 }
 
 // Save the model to a file.
 func (model *Model) Save(filename string) error {
-	cFilename := C.CString(filename)
-	defer C.free(unsafe.Pointer(cFilename))
-	result := C.save_model_wrap(model.model, cFilename)
+	if model.model == nil {
+		return ErrClosed
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New("Could not save model to file: " + filename)
+	}
 
-	if result == -1 {
+	if _, err := model.WriteTo(f); err != nil {
+		f.Close()
+		return errors.New("Could not save model to file: " + filename)
+	}
+
+	if err := f.Close(); err != nil {
 		return errors.New("Could not save model to file: " + filename)
 	}
 
 	return nil
 }
 
-func finalizeModel(model *Model) {
-	C.free_and_destroy_model_wrap(model.model)
-	model.problem = nil
+// WriteTo writes the model in the liblinear model format to a writer. The
+// number of bytes written is returned. If the model has a label
+// dictionary, feature dictionary, or feature hasher, it is written after
+// the weights, where it is ignored by liblinear.
+func (model *Model) WriteTo(w io.Writer) (int64, error) {
+	if model.model == nil {
+		return 0, ErrClosed
+	}
+
+	n, err := model.model.writeTo(w)
+	if err != nil {
+		return n, err
+	}
+
+	en, err := model.encoders.writeTo(w)
+
+	return n + en, err
+}
+
+// Close releases the model. Methods that return an error return
+// ErrClosed after the model is closed. Since prediction is implemented
+// in Go, a model does not hold C memory and closing a model is optional.
+func (model *Model) Close() error {
+	model.model = nil
+	return nil
 }
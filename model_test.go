@@ -4,14 +4,23 @@
 
 package golinear
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func simpleInstances() []TrainingInstance {
 	instances := []TrainingInstance{
-		{0, FromDenseVector([]float64{1, 1, 1, 0, 0})},
-		{0, FromDenseVector([]float64{0, 1, 0, 0, 0})},
-		{1, FromDenseVector([]float64{1, 0, 1, 1, 1})},
-		{1, FromDenseVector([]float64{0, 0, 0, 1, 1})}}
+		{Label: 0, Features: FromDenseVector([]float64{1, 1, 1, 0, 0})},
+		{Label: 0, Features: FromDenseVector([]float64{0, 1, 0, 0, 0})},
+		{Label: 1, Features: FromDenseVector([]float64{1, 0, 1, 1, 1})},
+		{Label: 1, Features: FromDenseVector([]float64{0, 0, 0, 1, 1})}}
 
 	return instances
 }
@@ -26,20 +35,47 @@ func simpleProblem(t *testing.T) *Problem {
 	return problem
 }
 
-func TestPredict(t *testing.T) {
-	problem := simpleProblem(t)
+// trainModel trains a model, the test is skipped if golinear was built
+// without training support.
+func trainModel(t *testing.T, param Parameters, problem *Problem) *Model {
+	model, err := TrainModel(param, problem)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
 
-	param := DefaultParameters()
+	return model
+}
 
-	model, err := TrainModel(param, problem)
+// modelWeights returns the weights of a binary model.
+func modelWeights(t *testing.T, model *Model) []float64 {
+	weights, err := model.Weights()
 	if err != nil {
-		t.Error("Could not train model: " + err.Error())
+		t.Fatal(err)
 	}
 
-	if model == nil {
-		return // We already reported the error.
+	return weights
+}
+
+// modelBias returns the bias of a binary model.
+func modelBias(t *testing.T, model *Model) float64 {
+	bias, err := model.Bias()
+	if err != nil {
+		t.Fatal(err)
 	}
 
+	return bias
+}
+
+func TestPredict(t *testing.T) {
+	problem := simpleProblem(t)
+
+	param := DefaultParameters()
+
+	model := trainModel(t, param, problem)
+
 	check1 := model.Predict(FromDenseVector([]float64{1, 1, 0, 0, 0}))
 	if check1 != 0 {
 		t.Errorf("Predict(check1) = %f, want 0.0", check1)
@@ -57,10 +93,7 @@ func TestPredictProbability(t *testing.T) {
 	param := DefaultParameters()
 	param.SolverType = NewL2RLogisticRegressionDefault()
 
-	model, err := TrainModel(param, problem)
-	if err != nil {
-		t.Error("Could not train model: " + err.Error())
-	}
+	model := trainModel(t, param, problem)
 
 	check1, probs1, err1 := model.PredictProbability(FromDenseVector([]float64{1, 1, 0, 0, 0}))
 
@@ -86,3 +119,419 @@ func TestPredictProbability(t *testing.T) {
 		t.Error("p(l1) <= p(l0), want p(l1) > p(l0)")
 	}
 }
+
+func threeClassProblem(t *testing.T) *Problem {
+	problem := NewProblem()
+
+	problem.Add(TrainingInstance{Label: 0, Features: FromDenseVector([]float64{1, 1, 0, 0, 0, 0})})
+	problem.Add(TrainingInstance{Label: 0, Features: FromDenseVector([]float64{1, 0, 0, 0, 0, 0})})
+	problem.Add(TrainingInstance{Label: 1, Features: FromDenseVector([]float64{0, 0, 1, 1, 0, 0})})
+	problem.Add(TrainingInstance{Label: 1, Features: FromDenseVector([]float64{0, 0, 0, 1, 0, 0})})
+	problem.Add(TrainingInstance{Label: 2, Features: FromDenseVector([]float64{0, 0, 0, 0, 1, 1})})
+	problem.Add(TrainingInstance{Label: 2, Features: FromDenseVector([]float64{0, 0, 0, 0, 0, 1})})
+
+	return problem
+}
+
+func TestWeightsMulti(t *testing.T) {
+	for _, solver := range []SolverType{NewL2RL2LossSvcDualDefault(), NewMCSVMCSDefault()} {
+		param := DefaultParameters()
+		param.SolverType = solver
+
+		model := trainModel(t, param, threeClassProblem(t))
+
+		weights, biases, err := model.WeightsMulti()
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := model.Labels()
+		if len(weights) != len(labels) || len(biases) != len(labels) {
+			t.Fatalf("len(weights) = %d, len(biases) = %d, want %d", len(weights),
+				len(biases), len(labels))
+		}
+
+		// The class with the highest activation should be the predicted class.
+		problem := threeClassProblem(t)
+		problem.Iterate(func(instance *TrainingInstance) bool {
+			best := 0
+			bestValue := dotProduct(weights[0], instance.Features) + biases[0]
+			for class := 1; class < len(weights); class++ {
+				value := dotProduct(weights[class], instance.Features) + biases[class]
+				if value > bestValue {
+					best, bestValue = class, value
+				}
+			}
+
+			if predicted := model.Predict(instance.Features); float64(labels[best]) != predicted {
+				t.Errorf("argmax(weights) = %d, want %f", labels[best], predicted)
+			}
+
+			return true
+		})
+	}
+}
+
+func TestWeightsMultiBinary(t *testing.T) {
+	model := trainModel(t, DefaultParameters(), simpleProblem(t))
+
+	weights, _, _ := model.WeightsMulti()
+	binary := modelWeights(t, model)
+
+	for i, w := range binary {
+		if weights[0][i] != w || weights[1][i] != -w {
+			t.Errorf("weights[*][%d] = (%f, %f), want (%f, %f)", i, weights[0][i],
+				weights[1][i], w, -w)
+		}
+	}
+}
+
+func dotProduct(weights []float64, features FeatureVector) float64 {
+	var sum float64
+	for _, f := range features {
+		if f.Index <= len(weights) {
+			sum += weights[f.Index-1] * f.Value
+		}
+	}
+	return sum
+}
+
+const threeClassModel = `solver_type MCSVM_CS
+nr_class 3
+label 3 1 2
+nr_feature 2
+bias -1
+w
+1 0 -1 
+0 1 -1 
+`
+
+func TestReadModel(t *testing.T) {
+	model, err := ReadModel(strings.NewReader(threeClassModel))
+	if err != nil {
+		t.Fatal("Could not read model: " + err.Error())
+	}
+
+	if labels := model.Labels(); len(labels) != 3 || labels[0] != 3 || labels[1] != 1 || labels[2] != 2 {
+		t.Errorf("Labels() = %v, want [3 1 2]", labels)
+	}
+
+	if label := model.Predict(FeatureVector{{1, 1}}); label != 3 {
+		t.Errorf("Predict({1:1}) = %f, want 3", label)
+	}
+
+	if label := model.Predict(FeatureVector{{2, 1}}); label != 1 {
+		t.Errorf("Predict({2:1}) = %f, want 1", label)
+	}
+
+	if label := model.Predict(FeatureVector{{1, -1}, {2, -1}}); label != 2 {
+		t.Errorf("Predict({1:-1, 2:-1}) = %f, want 2", label)
+	}
+
+	var buf bytes.Buffer
+	if _, err := model.WriteTo(&buf); err != nil {
+		t.Fatal("Could not write model: " + err.Error())
+	}
+
+	if buf.String() != threeClassModel {
+		t.Errorf("WriteTo() = %q, want %q", buf.String(), threeClassModel)
+	}
+}
+
+func TestReadModelInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"solver_type UNKNOWN\nnr_class 2\nlabel 0 1\nnr_feature 1\nbias -1\nw\n0\n",
+		"solver_type L2R_LR\nnr_class 2\nlabel 0 1\nnr_feature 2\nbias -1\nw\n0\n",
+		"solver_type L2R_LR\nnr_class 2\nlabel 0 1\nnr_feature 1\nbias -1\nw\nzero\n",
+		"solver_type L2R_LR\nnr_class 2\nlabel 0 1\nnr_feature 1\nbias -1\nbogus\nw\n0\n",
+	}
+
+	for _, model := range invalid {
+		if _, err := ReadModel(strings.NewReader(model)); err == nil {
+			t.Errorf("Invalid model should be rejected: %q", model)
+		}
+	}
+}
+
+func TestWriteToMatchesSave(t *testing.T) {
+	model := trainModel(t, DefaultParameters(), threeClassProblem(t))
+
+	dir, err := ioutil.TempDir("", "golinear")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "model")
+	if err := model.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := model.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Could not write model: " + err.Error())
+	}
+
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, want %d", n, buf.Len())
+	}
+
+	if !bytes.Equal(buf.Bytes(), saved) {
+		t.Errorf("WriteTo() = %q, want %q", buf.String(), string(saved))
+	}
+
+	read, err := ReadModel(&buf)
+	if err != nil {
+		t.Fatal("Could not read model: " + err.Error())
+	}
+
+	threeClassProblem(t).Iterate(func(instance *TrainingInstance) bool {
+		if got, want := read.Predict(instance.Features), model.Predict(instance.Features); got != want {
+			t.Errorf("Predict() = %f, want %f", got, want)
+		}
+		return true
+	})
+}
+
+func TestTrainInstanceWeights(t *testing.T) {
+	// Two instances with the same features, but different labels. The
+	// label of the instance with the highest weight should be predicted.
+	for _, label := range []float64{0, 1} {
+		problem := NewProblem()
+		for _, inst := range []TrainingInstance{
+			{Label: 0, Features: FeatureVector{{1, 1}}, Weight: 10 - 9*label},
+			{Label: 1, Features: FeatureVector{{1, 1}}, Weight: 1 + 9*label},
+		} {
+			if err := problem.Add(inst); err == ErrWeightsUnsupported {
+				t.Skip(err.Error())
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		param := DefaultParameters()
+		param.SolverType = NewL2RLogisticRegressionDefault()
+		model := trainModel(t, param, problem)
+
+		if pred := model.Predict(FeatureVector{{1, 1}}); pred != label {
+			t.Errorf("Predict() = %f, want %f", pred, label)
+		}
+	}
+}
+
+// skipUnsupported skips a test if the linked liblinear version does not
+// support a parameter.
+func skipUnsupported(t *testing.T, err error) {
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err.Error())
+	}
+}
+
+func TestTrainUnsupported(t *testing.T) {
+	problem := simpleProblem(t)
+	problem.SetBias(1)
+
+	for _, setParam := range []func(*Parameters){
+		func(param *Parameters) { param.Nu = 0.5 },
+		func(param *Parameters) { param.UnregularizedBias = true },
+		func(param *Parameters) { param.InitialSolution = []float64{0.5, 0.5} },
+	} {
+		param := DefaultParameters()
+		param.SolverType = NewL2RLogisticRegressionDefault()
+		setParam(&param)
+
+		// Parameters are either supported or rejected with ErrUnsupported.
+		_, err := TrainModel(param, problem)
+		if err == ErrTrainingUnavailable {
+			t.Skip(err.Error())
+		}
+		if err != nil && !errors.Is(err, ErrUnsupported) {
+			t.Errorf("TrainModel() = %v, want nil or %v", err, ErrUnsupported)
+		}
+	}
+}
+
+func TestTrainSvrSensitivity(t *testing.T) {
+	problem := NewProblem()
+	problem.Add(TrainingInstance{Label: 0.5, Features: FeatureVector{{1, 1}}})
+	problem.Add(TrainingInstance{Label: -0.5, Features: FeatureVector{{2, 1}}})
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RL2LossSvRegressionDefault()
+	param.P = 0
+
+	if w := modelWeights(t, trainModel(t, param, problem)); w[0] <= 0 || w[1] >= 0 {
+		t.Errorf("Weights() = %v, want a positive and a negative weight", w)
+	}
+
+	// All targets are within the insensitive zone of the loss.
+	param.P = 1
+
+	if w := modelWeights(t, trainModel(t, param, problem)); w[0] != 0 || w[1] != 0 {
+		t.Errorf("Weights() = %v, want [0 0]", w)
+	}
+}
+
+func TestTrainInitialSolution(t *testing.T) {
+	problem := simpleProblem(t)
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+	// Weights of missing features are zero.
+	param.InitialSolution = []float64{0.5, 0.5}
+
+	model, err := TrainModel(param, problem)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	skipUnsupported(t, err)
+	if err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
+
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		if label := model.Predict(instance.Features); label != instance.Label {
+			t.Errorf("Predict() = %f, want %f", label, instance.Label)
+		}
+		return true
+	})
+
+	// The initial solution is not supported by dual solvers.
+	param.SolverType = NewL2RLogisticRegressionDualDefault()
+	if _, err := TrainModel(param, problem); err == nil {
+		t.Error("Initial solution should be rejected for dual solvers")
+	}
+}
+
+func TestTrainUnregularizedBias(t *testing.T) {
+	problem := simpleProblem(t)
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+	param.UnregularizedBias = true
+
+	_, err := TrainModel(param, problem)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	skipUnsupported(t, err)
+	if err == nil {
+		t.Error("Unregularized bias without a problem bias should be rejected")
+	}
+
+	problem.SetBias(1)
+	if _, err := TrainModel(param, problem); err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
+}
+
+func TestTrainModelFrom(t *testing.T) {
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+
+	// Train the initial model on part of the problem.
+	initialProblem := NewProblem()
+	for _, instance := range simpleInstances()[1:3] {
+		initialProblem.Add(instance)
+	}
+	initial := trainModel(t, param, initialProblem)
+
+	problem := simpleProblem(t)
+	model, err := TrainModelFrom(param, problem, initial)
+	skipUnsupported(t, err)
+	if err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
+
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		if label := model.Predict(instance.Features); label != instance.Label {
+			t.Errorf("Predict() = %f, want %f", label, instance.Label)
+		}
+		return true
+	})
+
+	param.SolverType = NewL2RL2LossSvcPrimalDefault()
+	if _, err := TrainModelFrom(param, problem, initial); err == nil {
+		t.Error("Initial model trained with another solver should be rejected")
+	}
+}
+
+func TestWarmStartSolution(t *testing.T) {
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+
+	problem := simpleProblem(t)
+	problem.SetBias(1)
+	initial := trainModel(t, param, problem)
+
+	// Add a feature and swap the order of the labels.
+	swapped := NewProblem()
+	swapped.SetBias(1)
+	instances := simpleInstances()
+	for i := range instances {
+		instance := instances[len(instances)-i-1]
+		instance.Features = append(instance.Features, FeatureValue{6, 1})
+		swapped.Add(instance)
+	}
+
+	initSol, err := warmStartSolution(param, swapped, initial.model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weights, bias := modelWeights(t, initial), modelBias(t, initial)
+	check := make([]float64, 0, 7)
+	for _, w := range weights {
+		check = append(check, -w)
+	}
+	check = append(check, 0, -bias)
+
+	if len(initSol) != len(check) {
+		t.Fatalf("len(initSol) = %d, want %d", len(initSol), len(check))
+	}
+	for i := range check {
+		if initSol[i] != check[i] {
+			t.Errorf("initSol[%d] = %f, want %f", i, initSol[i], check[i])
+		}
+	}
+
+	threeClass := threeClassProblem(t)
+	threeClass.SetBias(1)
+	if _, err := warmStartSolution(param, threeClass, initial.model); err == nil {
+		t.Error("Problem with other labels should be rejected")
+	}
+
+	problem.SetBias(-1)
+	if _, err := warmStartSolution(param, problem, initial.model); err == nil {
+		t.Error("Problem without a bias should be rejected")
+	}
+}
+
+func TestModelClose(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+	if err := model.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := model.PredictDecisionValuesSlice(FeatureVector{{1, 1}}); err != ErrClosed {
+		t.Errorf("PredictDecisionValuesSlice() = %v, want %v", err, ErrClosed)
+	}
+
+	if _, err := model.WriteTo(ioutil.Discard); err != ErrClosed {
+		t.Errorf("WriteTo() = %v, want %v", err, ErrClosed)
+	}
+
+	if _, err := model.Weights(); err != ErrClosed {
+		t.Errorf("Weights() = %v, want %v", err, ErrClosed)
+	}
+
+	if label := model.Predict(FeatureVector{{1, 1}}); !math.IsNaN(label) {
+		t.Errorf("Predict() = %f, want NaN", label)
+	}
+}
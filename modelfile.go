@@ -0,0 +1,262 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Solver names, as used in liblinear model files.
+var solverNames = map[int]string{
+	solverL2RLR:            "L2R_LR",
+	solverL2RL2LossSvcDual: "L2R_L2LOSS_SVC_DUAL",
+	solverL2RL2LossSvc:     "L2R_L2LOSS_SVC",
+	solverL2RL1LossSvcDual: "L2R_L1LOSS_SVC_DUAL",
+	solverMCSVMCS:          "MCSVM_CS",
+	solverL1RL2LossSvc:     "L1R_L2LOSS_SVC",
+	solverL1RLR:            "L1R_LR",
+	solverL2RLRDual:        "L2R_LR_DUAL",
+	solverL2RL2LossSvr:     "L2R_L2LOSS_SVR",
+	solverL2RL2LossSvrDual: "L2R_L2LOSS_SVR_DUAL",
+	solverL2RL1LossSvrDual: "L2R_L1LOSS_SVR_DUAL",
+	solverOneClassSvm:      "ONECLASS_SVM",
+}
+
+// linearModel is the Go representation of a liblinear model file.
+type linearModel struct {
+	solverType int
+	nrClass    int
+	// The class labels, nil for regression and one-class models.
+	labels    []int
+	nrFeature int
+	bias      float64
+	// Offset of the decision function of one-class models.
+	rho float64
+	// The weights, stored feature-major. If the bias is non-negative,
+	// the weights of the bias are stored after those of the features.
+	w []float64
+}
+
+// nrWeightVectors returns the number of weight vectors that is stored
+// in the model.
+func (m *linearModel) nrWeightVectors() int {
+	if m.nrClass == 2 && m.solverType != solverMCSVMCS {
+		return 1
+	}
+
+	return m.nrClass
+}
+
+// wSize returns the number of weights per class, including the weight
+// of the bias.
+func (m *linearModel) wSize() int {
+	if m.bias >= 0 {
+		return m.nrFeature + 1
+	}
+
+	return m.nrFeature
+}
+
+// wordScanner reads the whitespace-separated fields of a model file.
+type wordScanner struct {
+	scanner *bufio.Scanner
+	// The kind of file that is read, used in error messages.
+	kind string
+}
+
+func newWordScanner(r io.Reader, kind string) *wordScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	return &wordScanner{scanner, kind}
+}
+
+// scan returns the next field. ok is false at the end of the input.
+func (s *wordScanner) scan() (field string, ok bool, err error) {
+	if !s.scanner.Scan() {
+		return "", false, s.scanner.Err()
+	}
+	return s.scanner.Text(), true, nil
+}
+
+func (s *wordScanner) next(what string) (string, error) {
+	field, ok, err := s.scan()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("Unexpected end of %s, expected: %s", s.kind, what)
+	}
+	return field, nil
+}
+
+func (s *wordScanner) nextInt(what string) (int, error) {
+	field, err := s.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.Atoi(field)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot parse %s: %s", what, field)
+	}
+	return v, nil
+}
+
+func (s *wordScanner) nextFloat(what string) (float64, error) {
+	field, err := s.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot parse %s: %s", what, field)
+	}
+	return v, nil
+}
+
+// readLinearModel reads a model in the liblinear model format.
+func readLinearModel(s *wordScanner) (*linearModel, error) {
+	m := &linearModel{solverType: -1, nrClass: -1, nrFeature: -1}
+
+header:
+	for {
+		cmd, err := s.next("header field or w")
+		if err != nil {
+			return nil, err
+		}
+
+		switch cmd {
+		case "solver_type":
+			name, err := s.next("solver type")
+			if err != nil {
+				return nil, err
+			}
+			if m.solverType, err = solverByName(name); err != nil {
+				return nil, err
+			}
+		case "nr_class":
+			if m.nrClass, err = s.nextInt("number of classes"); err != nil {
+				return nil, err
+			}
+		case "nr_feature":
+			if m.nrFeature, err = s.nextInt("number of features"); err != nil {
+				return nil, err
+			}
+		case "bias":
+			if m.bias, err = s.nextFloat("bias"); err != nil {
+				return nil, err
+			}
+		case "rho":
+			if m.rho, err = s.nextFloat("rho"); err != nil {
+				return nil, err
+			}
+		case "label":
+			if m.nrClass < 1 {
+				return nil, fmt.Errorf("Labels given before the number of classes")
+			}
+			m.labels = make([]int, m.nrClass)
+			for i := range m.labels {
+				if m.labels[i], err = s.nextInt("label"); err != nil {
+					return nil, err
+				}
+			}
+		case "w":
+			break header
+		default:
+			return nil, fmt.Errorf("Unknown text in model file: %s", cmd)
+		}
+	}
+
+	if m.solverType == -1 || m.nrClass < 1 || m.nrFeature < 0 {
+		return nil, fmt.Errorf("Incomplete model header")
+	}
+
+	if m.labels == nil && !m.isRegression() && !m.isOneClass() {
+		return nil, fmt.Errorf("Model does not contain labels")
+	}
+
+	if m.labels != nil && len(m.labels) != m.nrClass {
+		return nil, fmt.Errorf("Number of labels (%d) does not match the number of classes (%d)",
+			len(m.labels), m.nrClass)
+	}
+
+	m.w = make([]float64, m.wSize()*m.nrWeightVectors())
+	for i := range m.w {
+		var err error
+		if m.w[i], err = s.nextFloat("weight"); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// writeTo writes the model in the liblinear model format.
+func (m *linearModel) writeTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	fmt.Fprintf(bw, "solver_type %s\n", solverNames[m.solverType])
+	fmt.Fprintf(bw, "nr_class %d\n", m.nrClass)
+
+	if m.labels != nil {
+		bw.WriteString("label")
+		for _, label := range m.labels {
+			fmt.Fprintf(bw, " %d", label)
+		}
+		bw.WriteString("\n")
+	}
+
+	fmt.Fprintf(bw, "nr_feature %d\n", m.nrFeature)
+	fmt.Fprintf(bw, "bias %s\n", formatFloat(m.bias))
+
+	if m.solverType == solverOneClassSvm {
+		fmt.Fprintf(bw, "rho %s\n", formatFloat(m.rho))
+	}
+
+	bw.WriteString("w\n")
+	nWeights := m.nrWeightVectors()
+	for i := 0; i < m.wSize(); i++ {
+		for j := 0; j < nWeights; j++ {
+			bw.WriteString(formatFloat(m.w[i*nWeights+j]))
+			bw.WriteString(" ")
+		}
+		bw.WriteString("\n")
+	}
+
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+func solverByName(name string) (int, error) {
+	for solver, solverName := range solverNames {
+		if solverName == name {
+			return solver, nil
+		}
+	}
+
+	return 0, fmt.Errorf("Unknown solver type: %s", name)
+}
+
+// formatFloat formats a float like liblinear's %.17g.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', 17, 64)
+}
+
+// countingWriter counts the number of bytes written to the underlying
+// writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
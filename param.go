@@ -4,10 +4,29 @@
 
 package golinear
 
-/*
-#include "wrap.h"
-*/
-import "C"
+// Solver identifiers, as used by liblinear.
+const (
+	solverL2RLR            = 0
+	solverL2RL2LossSvcDual = 1
+	solverL2RL2LossSvc     = 2
+	solverL2RL1LossSvcDual = 3
+	solverMCSVMCS          = 4
+	solverL1RL2LossSvc     = 5
+	solverL1RLR            = 6
+	solverL2RLRDual        = 7
+	solverL2RL2LossSvr     = 11
+	solverL2RL2LossSvrDual = 12
+	solverL2RL1LossSvrDual = 13
+	solverOneClassSvm      = 21
+)
+
+// isRegressionSolver returns true if the solver is a support vector
+// regression solver.
+func isRegressionSolver(solverType int) bool {
+	return solverType == solverL2RL2LossSvr ||
+		solverType == solverL2RL2LossSvrDual ||
+		solverType == solverL2RL1LossSvrDual
+}
 
 // Parameters for training a linear model.
 type Parameters struct {
@@ -21,6 +40,26 @@ type Parameters struct {
 	// The number of threads to use if liblinear is built with OpenMP support.
 	// Default value is 0 and will use all the cores.
 	NThreads int
+
+	// The sensitivity of the epsilon-insensitive loss of support vector
+	// regression.
+	P float64
+	// The fraction of outliers of one-class SVMs. If zero, liblinear's
+	// default of 0.5 is used. The nu of a solver constructed with
+	// NewOneClassSvm takes precedence. Requires liblinear 2.40 or later.
+	Nu float64
+	// The initial weights for the L2R_LR, L2R_L2LOSS_SVC, and
+	// L2R_L2LOSS_SVR solvers. The weights are stored as in the model,
+	// the weight of the bias follows the weights of the features. Missing
+	// weights are zero. Requires liblinear 2.10 or later.
+	InitialSolution []float64
+	// Do not regularize the bias. This requires a problem bias of 1 and
+	// liblinear 2.40 or later.
+	UnregularizedBias bool
+
+	// The function that receives the messages that liblinear prints
+	// during training. If nil, the messages are printed to stdout.
+	Print PrintFunc
 }
 
 // ClassWeight instances are used in the solver parameters to scale
@@ -32,14 +71,16 @@ type ClassWeight struct {
 
 // A SolverType specifies represents one of the liblinear solvers.
 type SolverType struct {
-	solverType C.int
-	epsilon    C.double
+	solverType int
+	epsilon    float64
+	// The fraction of outliers, for one-class SVMs.
+	nu float64
 }
 
 // NewL2RLogisticRegression creates an L2-regularized logistic regression
 // (primal) solver.
 func NewL2RLogisticRegression(epsilon float64) SolverType {
-	return SolverType{C.L2R_LR, C.double(epsilon)}
+	return SolverType{solverType: solverL2RLR, epsilon: epsilon}
 }
 
 // NewL2RLogisticRegressionDefault creates an L2-regularized logistic
@@ -51,7 +92,7 @@ func NewL2RLogisticRegressionDefault() SolverType {
 // NewL2RL2LossSvcDual creates an L2-regularized L2-loss support vector
 // classification (dual) solver.
 func NewL2RL2LossSvcDual(epsilon float64) SolverType {
-	return SolverType{C.L2R_L2LOSS_SVC_DUAL, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL2LossSvcDual, epsilon: epsilon}
 }
 
 // NewL2RL2LossSvcDualDefault creates an L2-regularized L2-loss support
@@ -63,7 +104,7 @@ func NewL2RL2LossSvcDualDefault() SolverType {
 // NewL2RL2LossSvcPrimal creates an L2-regularized L2-loss support vector
 // classification (primal) solver.
 func NewL2RL2LossSvcPrimal(epsilon float64) SolverType {
-	return SolverType{C.L2R_L2LOSS_SVC, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL2LossSvc, epsilon: epsilon}
 }
 
 // NewL2RL2LossSvcPrimalDefault creates an L2-regularized L2-loss support
@@ -75,7 +116,7 @@ func NewL2RL2LossSvcPrimalDefault() SolverType {
 // NewL2RL1LossSvcDual creates an L2-regularized L1-loss support vector
 // classification (dual) solver.
 func NewL2RL1LossSvcDual(epsilon float64) SolverType {
-	return SolverType{C.L2R_L1LOSS_SVC_DUAL, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL1LossSvcDual, epsilon: epsilon}
 }
 
 // NewL2RL1LossSvcDualDefault creates an L2-regularized L1-loss support
@@ -87,7 +128,7 @@ func NewL2RL1LossSvcDualDefault() SolverType {
 // NewMCSVMCS creates a Support vector classification solver
 // (Crammer and Singer).
 func NewMCSVMCS(epsilon float64) SolverType {
-	return SolverType{C.MCSVM_CS, C.double(epsilon)}
+	return SolverType{solverType: solverMCSVMCS, epsilon: epsilon}
 }
 
 // NewMCSVMCSDefault creates a Support vector classification solver
@@ -99,7 +140,7 @@ func NewMCSVMCSDefault() SolverType {
 // NewL1RL2LossSvc creates an L1-regularized L2-loss support vector
 // classification solver.
 func NewL1RL2LossSvc(epsilon float64) SolverType {
-	return SolverType{C.L1R_L2LOSS_SVC, C.double(epsilon)}
+	return SolverType{solverType: solverL1RL2LossSvc, epsilon: epsilon}
 }
 
 // NewL1RL2LossSvcDefault creates an L1-regularized L2-loss support
@@ -111,7 +152,7 @@ func NewL1RL2LossSvcDefault() SolverType {
 // NewL1RLogisticRegression creates an L1-regularized logistic
 // regression solver.
 func NewL1RLogisticRegression(epsilon float64) SolverType {
-	return SolverType{C.L1R_LR, C.double(epsilon)}
+	return SolverType{solverType: solverL1RLR, epsilon: epsilon}
 }
 
 // NewL1RLogisticRegressionDefault creates an L1-regularized logistic
@@ -123,7 +164,7 @@ func NewL1RLogisticRegressionDefault() SolverType {
 // NewL2RLogisticRegressionDual creates an L2-regularized logistic
 // regression (dual) for regression solver.
 func NewL2RLogisticRegressionDual(epsilon float64) SolverType {
-	return SolverType{C.L2R_LR_DUAL, C.double(epsilon)}
+	return SolverType{solverType: solverL2RLRDual, epsilon: epsilon}
 }
 
 // NewL2RLogisticRegressionDualDefault creates an L2-regularized logistic
@@ -135,7 +176,7 @@ func NewL2RLogisticRegressionDualDefault() SolverType {
 // NewL2RL2LossSvRegression creates an L2-regularized L2-loss support vector
 // regression (primal) solver.
 func NewL2RL2LossSvRegression(epsilon float64) SolverType {
-	return SolverType{C.L2R_L2LOSS_SVR, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL2LossSvr, epsilon: epsilon}
 }
 
 // NewL2RL2LossSvRegressionDefault creates an L2-regularized L2-loss support
@@ -147,7 +188,7 @@ func NewL2RL2LossSvRegressionDefault() SolverType {
 // NewL2RL2LossSvRegressionDual creates an L2-regularized L2-loss support
 // vector regression (dual) solver.
 func NewL2RL2LossSvRegressionDual(epsilon float64) SolverType {
-	return SolverType{C.L2R_L2LOSS_SVR_DUAL, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL2LossSvrDual, epsilon: epsilon}
 }
 
 // NewL2RL2LossSvRegressionDualDefault creates an L2-regularized L2-loss
@@ -159,7 +200,7 @@ func NewL2RL2LossSvRegressionDualDefault() SolverType {
 // NewL2RL1LossSvRegressionDual creates an L2-regularized L1-loss support
 // vector regression solver (dual).
 func NewL2RL1LossSvRegressionDual(epsilon float64) SolverType {
-	return SolverType{C.L2R_L1LOSS_SVR_DUAL, C.double(epsilon)}
+	return SolverType{solverType: solverL2RL1LossSvrDual, epsilon: epsilon}
 }
 
 // NewL2RL1LossSvRegressionDualDefault creates an L2-regularized L1-loss
@@ -168,34 +209,29 @@ func NewL2RL1LossSvRegressionDualDefault() SolverType {
 	return NewL2RL1LossSvRegressionDual(0.1)
 }
 
+// NewOneClassSvm creates a one-class support vector machine (dual)
+// solver. nu is an upper bound on the fraction of training instances
+// that is considered to be an outlier and should be in (0, 1]. The
+// labels of training instances are not used. Requires liblinear 2.40
+// or later.
+func NewOneClassSvm(nu, epsilon float64) SolverType {
+	return SolverType{solverType: solverOneClassSvm, epsilon: epsilon, nu: nu}
+}
+
+// NewOneClassSvmDefault creates a one-class support vector machine
+// (dual) solver, nu = 0.5, epsilon = 0.01.
+func NewOneClassSvmDefault() SolverType {
+	return NewOneClassSvm(0.5, 0.01)
+}
+
 // DefaultParameters returns a set of reasonable default parameters:
-// L2-regularized L2-loss spport vector classification (dual) and a
-// constraint violation cost of 1.
+// L2-regularized L2-loss spport vector classification (dual), a
+// constraint violation cost of 1, and a support vector regression loss
+// sensitivity of 0.1.
 func DefaultParameters() Parameters {
-	return Parameters{NewL2RL2LossSvcDualDefault(), 1, nil, 0}
-}
-
-func toCParameter(param Parameters) *C.parameter_t {
-	cParam := newParameter()
-
-	cParam.solver_type = param.SolverType.solverType
-	cParam.eps = param.SolverType.epsilon
-	cParam.C = C.double(param.Cost)
-
-	// Copy relative costs into C structure.
-	n := len(param.RelCosts)
-	if n > 0 {
-		cParam.nr_weight = C.int(n)
-		cParam.weight_label = newLabels(C.int(n))
-		cParam.weight = newDouble(C.size_t(n))
-		for i, weight := range param.RelCosts {
-			C.set_int_idx(cParam.weight_label, C.int(i), C.int(weight.Label))
-			C.set_double_idx(cParam.weight, C.int(i), C.double(weight.Value))
-		}
+	return Parameters{
+		SolverType: NewL2RL2LossSvcDualDefault(),
+		Cost:       1,
+		P:          0.1,
 	}
-
-	// Set the number of threads to use by OpenMP.
-	C.parameter_set_nthreads(cParam, C.int(param.NThreads))
-
-	return cParam
 }
@@ -0,0 +1,113 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import "math"
+
+// isRegression returns true if the model was trained using a support
+// vector regression solver.
+func (m *linearModel) isRegression() bool {
+	return isRegressionSolver(m.solverType)
+}
+
+// isOneClass returns true if the model is a one-class SVM.
+func (m *linearModel) isOneClass() bool {
+	return m.solverType == solverOneClassSvm
+}
+
+// isProbability returns true if the model provides probability estimates.
+func (m *linearModel) isProbability() bool {
+	return m.solverType == solverL2RLR ||
+		m.solverType == solverL2RLRDual ||
+		m.solverType == solverL1RLR
+}
+
+// predictValues computes the decision values of an instance and returns
+// the predicted label. decValues should have space for at least
+// nrWeightVectors() values. This is a port of liblinear's predict_values,
+// where the bias is added as liblinear's predict tool does.
+func (m *linearModel) predictValues(nodes []FeatureValue, decValues []float64) float64 {
+	nWeights := m.nrWeightVectors()
+	decValues = decValues[:nWeights]
+
+	for i := range decValues {
+		decValues[i] = 0
+	}
+
+	for _, node := range nodes {
+		// Features that were not seen during training are ignored.
+		if node.Index < 1 || node.Index > m.nrFeature {
+			continue
+		}
+
+		offset := (node.Index - 1) * nWeights
+		for i := range decValues {
+			decValues[i] += m.w[offset+i] * node.Value
+		}
+	}
+
+	if m.bias >= 0 {
+		offset := m.nrFeature * nWeights
+		for i := range decValues {
+			decValues[i] += m.w[offset+i] * m.bias
+		}
+	}
+
+	if m.isOneClass() {
+		decValues[0] -= m.rho
+	}
+
+	if m.nrClass == 2 {
+		switch {
+		case m.isRegression():
+			return decValues[0]
+		case m.isOneClass():
+			if decValues[0] > 0 {
+				return 1
+			}
+			return -1
+		case decValues[0] > 0:
+			return float64(m.labels[0])
+		default:
+			return float64(m.labels[1])
+		}
+	}
+
+	maxIdx := 0
+	for i := 1; i < m.nrClass; i++ {
+		if decValues[i] > decValues[maxIdx] {
+			maxIdx = i
+		}
+	}
+
+	return float64(m.labels[maxIdx])
+}
+
+// predictProbability computes the probability of each class and returns
+// the predicted label. probs should have space for nrClass values. The
+// model should provide probability estimates. This is a port of
+// liblinear's predict_probability.
+func (m *linearModel) predictProbability(nodes []FeatureValue, probs []float64) float64 {
+	label := m.predictValues(nodes, probs)
+
+	nWeights := m.nrWeightVectors()
+	for i := 0; i < nWeights; i++ {
+		probs[i] = 1 / (1 + math.Exp(-probs[i]))
+	}
+
+	if m.nrClass == 2 {
+		probs[1] = 1 - probs[0]
+	} else {
+		var sum float64
+		for i := 0; i < m.nrClass; i++ {
+			sum += probs[i]
+		}
+		for i := 0; i < m.nrClass; i++ {
+			probs[i] /= sum
+		}
+	}
+
+	return label
+}
@@ -0,0 +1,357 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+const binaryLRModel = `solver_type L2R_LR
+nr_class 2
+label 1 -1
+nr_feature 2
+bias 1
+w
+2 
+-1 
+0.5 
+`
+
+const regressionModel = `solver_type L2R_L2LOSS_SVR
+nr_class 2
+nr_feature 2
+bias -1
+w
+2 
+-1 
+`
+
+const oneClassModel = `solver_type ONECLASS_SVM
+nr_class 2
+nr_feature 2
+bias -1
+rho 0.5
+w
+1 
+1 
+`
+
+func readTestModel(t *testing.T, model string) *Model {
+	m, err := ReadModel(strings.NewReader(model))
+	if err != nil {
+		t.Fatal("Could not read model: " + err.Error())
+	}
+	return m
+}
+
+func TestPredictBias(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	// 2 * 1 - 1 * 1 + 0.5 * 1 = 1.5
+	label, values, _ := model.PredictDecisionValuesSlice(FeatureVector{{1, 1}, {2, 1}})
+	if label != 1 || values[0] != 1.5 {
+		t.Errorf("PredictDecisionValuesSlice() = (%f, %f), want (1, 1.5)", label, values[0])
+	}
+
+	// Features that were not seen during training are ignored.
+	label, values, _ = model.PredictDecisionValuesSlice(FeatureVector{{2, 1}, {3, 10}})
+	if label != -1 || values[0] != -0.5 {
+		t.Errorf("PredictDecisionValuesSlice() = (%f, %f), want (-1, -0.5)", label, values[0])
+	}
+}
+
+func TestPredictProbabilityBinary(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	label, probs, err := model.PredictProbabilitySlice(FeatureVector{{1, 1}, {2, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := 1 / (1 + math.Exp(-1.5))
+	if label != 1 || math.Abs(probs[0]-want) > 1e-15 || math.Abs(probs[1]-(1-want)) > 1e-15 {
+		t.Errorf("PredictProbabilitySlice() = (%f, %v), want (1, [%f %f])", label, probs,
+			want, 1-want)
+	}
+}
+
+func TestPredictProbabilityMulti(t *testing.T) {
+	model := readTestModel(t, strings.Replace(threeClassModel, "MCSVM_CS", "L2R_LR", 1))
+
+	label, probs, err := model.PredictProbabilitySlice(FeatureVector{{1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sigmoid := func(v float64) float64 { return 1 / (1 + math.Exp(-v)) }
+	sum := sigmoid(1) + sigmoid(0) + sigmoid(-1)
+	want := []float64{sigmoid(1) / sum, sigmoid(0) / sum, sigmoid(-1) / sum}
+
+	if label != 3 {
+		t.Errorf("label = %f, want 3", label)
+	}
+
+	for i := range want {
+		if math.Abs(probs[i]-want[i]) > 1e-15 {
+			t.Errorf("probs[%d] = %f, want %f", i, probs[i], want[i])
+		}
+	}
+}
+
+func TestSupportsProbability(t *testing.T) {
+	if !readTestModel(t, binaryLRModel).SupportsProbability() {
+		t.Error("Logistic regression model should support probabilities")
+	}
+
+	for _, model := range []string{threeClassModel, regressionModel, oneClassModel} {
+		if readTestModel(t, model).SupportsProbability() {
+			t.Errorf("Model should not support probabilities:\n%s", model)
+		}
+	}
+}
+
+func TestPredictProbabilityUnsupported(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	if _, _, err := model.PredictProbabilitySlice(FeatureVector{{1, 1}}); !errors.Is(err, ErrNoProbabilities) {
+		t.Errorf("PredictProbabilitySlice() = %v, want %v", err, ErrNoProbabilities)
+	}
+
+	if _, _, err := model.PredictProbability(FeatureVector{{1, 1}}); !errors.Is(err, ErrNoProbabilities) {
+		t.Errorf("PredictProbability() = %v, want %v", err, ErrNoProbabilities)
+	}
+
+	if _, _, err := model.PredictProbabilityBatch([]FeatureVector{{{1, 1}}}); !errors.Is(err, ErrNoProbabilities) {
+		t.Errorf("PredictProbabilityBatch() = %v, want %v", err, ErrNoProbabilities)
+	}
+}
+
+func TestWeightsNotBinary(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	if _, err := model.Weights(); !errors.Is(err, ErrNotBinary) {
+		t.Errorf("Weights() = %v, want %v", err, ErrNotBinary)
+	}
+
+	if _, err := model.Bias(); !errors.Is(err, ErrNotBinary) {
+		t.Errorf("Bias() = %v, want %v", err, ErrNotBinary)
+	}
+}
+
+func TestPredictRegression(t *testing.T) {
+	model := readTestModel(t, regressionModel)
+
+	if v := model.Predict(FeatureVector{{1, 1}, {2, 0.5}}); v != 1.5 {
+		t.Errorf("Predict() = %f, want 1.5", v)
+	}
+}
+
+func TestTrainBias(t *testing.T) {
+	problem := simpleProblem(t)
+	problem.SetBias(1)
+
+	model := trainModel(t, DefaultParameters(), problem)
+
+	// The bias should not be counted as a feature.
+	if n := len(modelWeights(t, model)); n != 5 {
+		t.Errorf("len(Weights()) = %d, want 5", n)
+	}
+
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		if label := model.Predict(instance.Features); label != instance.Label {
+			t.Errorf("Predict() = %f, want %f", label, instance.Label)
+		}
+		return true
+	})
+}
+
+func TestPredictOneClass(t *testing.T) {
+	model := readTestModel(t, oneClassModel)
+
+	// 1 * 0.5 + 1 * 0.25 - 0.5 = 0.25
+	label, values, err := model.PredictDecisionValuesSlice(FeatureVector{{1, 0.5}, {2, 0.25}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != 1 || values[0] != 0.25 {
+		t.Errorf("PredictDecisionValuesSlice() = (%f, %f), want (1, 0.25)", label, values[0])
+	}
+
+	if label := model.Predict(FeatureVector{{1, 0.25}}); label != -1 {
+		t.Errorf("Predict() = %f, want -1", label)
+	}
+
+	if labels := model.Labels(); len(labels) != 2 || labels[0] != 1 || labels[1] != -1 {
+		t.Errorf("Labels() = %v, want [1 -1]", labels)
+	}
+
+	if rho := model.Rho(); rho != 0.5 {
+		t.Errorf("Rho() = %f, want 0.5", rho)
+	}
+
+	if bias := modelBias(t, model); bias != -0.5 {
+		t.Errorf("Bias() = %f, want -0.5", bias)
+	}
+}
+
+func TestTrainOneClass(t *testing.T) {
+	problem := NewProblem()
+	for _, fv := range [][]float64{{1, 1}, {1.1, 0.9}, {0.9, 1.1}, {1, 1.05}} {
+		// The label is not used.
+		problem.Add(TrainingInstance{Features: FromDenseVector(fv)})
+	}
+
+	param := DefaultParameters()
+	param.SolverType = NewOneClassSvm(0.1, 0.01)
+
+	model, err := TrainModel(param, problem)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	skipUnsupported(t, err)
+	if err != nil {
+		t.Fatal("Could not train model: " + err.Error())
+	}
+
+	if label := model.Predict(FromDenseVector([]float64{1.5, 1.5})); label != 1 {
+		t.Errorf("Predict() = %f, want 1", label)
+	}
+
+	if label := model.Predict(FromDenseVector([]float64{0.01, 0.01})); label != -1 {
+		t.Errorf("Predict() = %f, want -1", label)
+	}
+
+	// The weights and bias should give the decision value.
+	instance := FromDenseVector([]float64{0.5, 1})
+	_, values, _ := model.PredictDecisionValuesSlice(instance)
+	if v := dotProduct(modelWeights(t, model), instance) + modelBias(t, model); math.Abs(v-values[0]) > 1e-10 {
+		t.Errorf("Weights() and Bias() give %f, want %f", v, values[0])
+	}
+}
+
+func TestPredictTopK(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+
+	// Decision values: 3: 1, 1: 0.5, 2: -1.5
+	top, err := model.PredictTopK(FeatureVector{{1, 1}, {2, 0.5}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LabelScore{{3, 1}, {1, 0.5}}
+	if len(top) != len(want) {
+		t.Fatalf("PredictTopK() = %v, want %v", top, want)
+	}
+	for i := range want {
+		if top[i] != want[i] {
+			t.Errorf("PredictTopK()[%d] = %v, want %v", i, top[i], want[i])
+		}
+	}
+
+	if top, _ := model.PredictTopK(FeatureVector{{1, 1}}, 10); len(top) != 3 {
+		t.Errorf("len(PredictTopK()) = %d, want 3", len(top))
+	}
+
+	if top, _ := model.PredictTopK(FeatureVector{{1, 1}}, 0); len(top) != 0 {
+		t.Errorf("len(PredictTopK()) = %d, want 0", len(top))
+	}
+}
+
+func TestPredictTopKProbability(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	top, err := model.PredictTopK(FeatureVector{{1, 1}, {2, 1}}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := 1 / (1 + math.Exp(-1.5))
+	if len(top) != 2 || top[0].Label != 1 || top[1].Label != -1 ||
+		math.Abs(top[0].Score-want) > 1e-15 || math.Abs(top[1].Score-(1-want)) > 1e-15 {
+		t.Errorf("PredictTopK() = %v, want [{1 %f} {-1 %f}]", top, want, 1-want)
+	}
+
+	if _, err := readTestModel(t, regressionModel).PredictTopK(FeatureVector{{1, 1}}, 1); err == nil {
+		t.Error("Regression models should be rejected")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	model := readTestModel(t, binaryLRModel)
+
+	// Label 1: 2 * 1 - 1 * 3 + 0.5 * 1 = -0.5, so label -1 is predicted.
+	e, err := model.Explain(FeatureVector{{1, 1}, {2, 3}, {3, 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Label != -1 || e.DecisionValue != 0.5 || e.Bias != -0.5 {
+		t.Errorf("Explain() = (%d, %f, %f), want (-1, 0.5, -0.5)", e.Label, e.DecisionValue, e.Bias)
+	}
+
+	// Unseen features are omitted, contributions are sorted by magnitude.
+	want := []FeatureContribution{{2, "", 3, 1, 3}, {1, "", 1, -2, -2}}
+	if len(e.Contributions) != len(want) {
+		t.Fatalf("Contributions = %v, want %v", e.Contributions, want)
+	}
+	for i := range want {
+		if e.Contributions[i] != want[i] {
+			t.Errorf("Contributions[%d] = %v, want %v", i, e.Contributions[i], want[i])
+		}
+	}
+
+	e, err = model.ExplainLabel(FeatureVector{{1, 1}, {2, 3}}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Label != 1 || e.DecisionValue != -0.5 {
+		t.Errorf("ExplainLabel() = (%d, %f), want (1, -0.5)", e.Label, e.DecisionValue)
+	}
+
+	if _, err := model.ExplainLabel(FeatureVector{{1, 1}}, 2); err == nil {
+		t.Error("Unknown labels should be rejected")
+	}
+}
+
+func TestExplainMulti(t *testing.T) {
+	model := readTestModel(t, threeClassModel)
+	instance := FeatureVector{{1, 1}, {2, 0.5}}
+
+	_, values, _ := model.PredictDecisionValuesSlice(instance)
+	for i, label := range model.Labels() {
+		e, err := model.ExplainLabel(instance, label)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if e.DecisionValue != values[i] {
+			t.Errorf("ExplainLabel(%d) = %f, want %f", label, e.DecisionValue, values[i])
+		}
+	}
+
+	e, err := model.Explain(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Label != 3 {
+		t.Errorf("Explain() = %d, want 3", e.Label)
+	}
+}
+
+func TestExplainOneClass(t *testing.T) {
+	model := readTestModel(t, oneClassModel)
+
+	e, err := model.Explain(FeatureVector{{1, 0.5}, {2, 0.25}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Label != 1 || e.DecisionValue != 0.25 || e.Bias != -0.5 {
+		t.Errorf("Explain() = (%d, %f, %f), want (1, 0.25, -0.5)", e.Label, e.DecisionValue, e.Bias)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// A PrintFunc receives the messages that liblinear prints during
+// training, such as the progress of the solver and warnings. A message
+// is not necessarily a complete line.
+type PrintFunc func(message string)
+
+// DiscardPrintFunc is a PrintFunc that discards all messages.
+func DiscardPrintFunc(message string) {}
+
+// WriterPrintFunc returns a PrintFunc that writes messages to a writer.
+// Write errors are ignored.
+func WriterPrintFunc(w io.Writer) PrintFunc {
+	var mu sync.Mutex
+	return func(message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		io.WriteString(w, message)
+	}
+}
+
+// LoggerPrintFunc returns a PrintFunc that logs messages using a
+// structured logger with the given level. Each line is logged as a
+// separate record, empty lines are not logged. The PrintFunc can be
+// shared by concurrent training calls, but the lines of concurrent
+// calls may be interleaved.
+func LoggerPrintFunc(logger *slog.Logger, level slog.Level) PrintFunc {
+	var mu sync.Mutex
+	var pending string
+
+	return func(message string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Log complete lines, keep the remainder for the next message.
+		lines := strings.Split(pending+message, "\n")
+		pending = lines[len(lines)-1]
+
+		for _, line := range lines[:len(lines)-1] {
+			if line = strings.TrimSpace(line); line != "" {
+				logger.Log(context.Background(), level, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+/*
+#include "wrap.h"
+*/
+import "C"
+
+import "runtime/cgo"
+
+func init() {
+	C.print_init()
+}
+
+//export goPrintString
+func goPrintString(handle C.uintptr_t, message *C.char) {
+	printFunc := cgo.Handle(handle).Value().(PrintFunc)
+	printFunc(C.GoString(message))
+}
+
+// newPrintHandle returns the handle of a print function that can be
+// passed to the wrappers of liblinear's training functions. The handle
+// is zero if the print function is nil, in which case liblinear prints
+// to stdout. The returned function releases the handle.
+func newPrintHandle(printFunc PrintFunc) (C.uintptr_t, func()) {
+	if printFunc == nil {
+		return 0, func() {}
+	}
+
+	handle := cgo.NewHandle(printFunc)
+	return C.uintptr_t(handle), handle.Delete
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestTrainPrintFunc(t *testing.T) {
+	var messages []string
+
+	param := DefaultParameters()
+	param.Print = func(message string) {
+		messages = append(messages, message)
+	}
+
+	trainModel(t, param, simpleProblem(t))
+
+	if len(messages) == 0 {
+		t.Error("No training messages were received")
+	}
+}
+
+func TestWriterPrintFunc(t *testing.T) {
+	var buf bytes.Buffer
+
+	param := DefaultParameters()
+	param.Print = WriterPrintFunc(&buf)
+
+	trainModel(t, param, simpleProblem(t))
+
+	if buf.Len() == 0 {
+		t.Error("No training messages were written")
+	}
+}
+
+func TestLoggerPrintFunc(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	printFunc := LoggerPrintFunc(logger, slog.LevelDebug)
+	printFunc("..")
+	printFunc("*.\noptimization finished\n")
+	printFunc("\nincomplete")
+
+	check := "level=DEBUG msg=..*.\nlevel=DEBUG msg=\"optimization finished\"\n"
+	if log := buf.String(); log != check {
+		t.Errorf("log = %q, want %q", log, check)
+	}
+
+	if strings.Contains(buf.String(), "incomplete") {
+		t.Error("Incomplete lines should not be logged")
+	}
+}
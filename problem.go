@@ -4,17 +4,9 @@
 
 package golinear
 
-/*
-#cgo CFLAGS: -Wall -Wconversion -O3
-#cgo LDFLAGS: -llinear -lstdc++ -lm
-#include <stddef.h>
-#include "wrap.h"
-*/
-import "C"
-
 import (
 	"fmt"
-	"runtime"
+	"math"
 	"sort"
 )
 
@@ -36,32 +28,14 @@ type byIndex struct{ FeatureVector }
 // indicating the class label. In regression, the label is the
 // target value, which can be any real number. The label is not used
 // for one-class SVMs.
+//
+// The weight of an instance scales its loss during training. Since
+// zero is the default value of Weight, a weight of zero means the
+// default weight 1. Weights must not be negative, NaN or infinite.
 type TrainingInstance struct {
 	Label    float64
 	Features FeatureVector
-}
-
-// A Problem is a set of instances and corresponding labels.
-type Problem struct {
-	problem *C.problem_t
-	insts   []*C.feature_node_t
-}
-
-// NewProblem constructs a new problem instance. Problems are used
-// to store training instances.
-func NewProblem() *Problem {
-	cProblem := newProblem()
-	problem := &Problem{cProblem, nil}
-	runtime.SetFinalizer(problem, finalizeProblem)
-	return problem
-}
-
-func finalizeProblem(p *Problem) {
-	for _, nodes := range p.insts {
-		C.nodes_free(nodes)
-	}
-	p.insts = nil
-	C.problem_free(p.problem)
+	Weight   float64
 }
 
 // FromDenseVector convert sa dense feature vector, represented as a slice
@@ -81,71 +55,11 @@ func FromDenseVector(denseVector []float64) FeatureVector {
 	return fv
 }
 
-func cNodes(nodes []FeatureValue) *C.feature_node_t {
-	n := newNodes(C.size_t(len(nodes)))
-
-	for idx, val := range nodes {
-		C.nodes_put(n, C.size_t(idx), C.int(val.Index), C.double(val.Value))
-	}
-
-	return n
-}
-
-// Add adds a training instance to the problem.
-func (problem *Problem) Add(trainInst TrainingInstance) error {
-	if err := verifyFeatureIndices(trainInst.Features); err != nil {
-		return err
-	}
-
-	features := sortedFeatureVector(trainInst.Features)
-
-	nodes := newNodes(C.size_t(len(features)))
-	problem.insts = append(problem.insts, nodes)
-
-	for idx, val := range features {
-		C.nodes_put(nodes, C.size_t(idx), C.int(val.Index), C.double(val.Value))
-	}
-
-	C.problem_add_train_inst(problem.problem, nodes, C.double(trainInst.Label))
-
-	return nil
-}
-
-// Bias return the bias term.
-func (problem *Problem) Bias() float64 {
-	return float64(C.problem_bias(problem.problem))
-}
-
-// SetBias sets the bias term. Setting this value to non-zero amounts to
-// adding an extra feature to each instance with the bias as its value.
-func (problem *Problem) SetBias(bias float64) {
-	C.set_problem_bias(problem.problem, C.double(bias))
-}
-
 // ProblemIterFunc is the function prototype for iteration over problems.
 // The function should return 'true' if the iteration should continue or
 // 'false' otherwise.
 type ProblemIterFunc func(instance *TrainingInstance) bool
 
-// Iterate over the training instances in a problem.
-func (problem *Problem) Iterate(fun ProblemIterFunc) {
-	for i := 0; i < int(problem.problem.l); i++ {
-		label := float64(C.get_double_idx(problem.problem.y, C.int(i)))
-		cNodes := C.nodes_vector_get(problem.problem, C.size_t(i))
-
-		fVals := make(FeatureVector, 0)
-		var j C.size_t
-		for j = 0; C.nodes_get(cNodes, j).index != -1; j++ {
-			cNode := C.nodes_get(cNodes, j)
-			fVals = append(fVals, FeatureValue{int(cNode.index), float64(cNode.value)})
-		}
-
-		if !fun(&TrainingInstance{label, fVals}) {
-			break
-		}
-	}
-}
-
 // Helper functions
 
 func sortedFeatureVector(fv FeatureVector) FeatureVector {
@@ -157,6 +71,20 @@ func sortedFeatureVector(fv FeatureVector) FeatureVector {
 	return sorted
 }
 
+// instanceWeight returns the weight of a training instance, where the
+// default weight is used when the weight is zero. An error is returned
+// for negative, NaN and infinite weights.
+func instanceWeight(trainInst TrainingInstance) (float64, error) {
+	switch {
+	case trainInst.Weight == 0:
+		return 1, nil
+	case trainInst.Weight < 0 || math.IsNaN(trainInst.Weight) || math.IsInf(trainInst.Weight, 0):
+		return 0, fmt.Errorf("Instance weight should be positive: %f", trainInst.Weight)
+	default:
+		return trainInst.Weight, nil
+	}
+}
+
 func verifyFeatureIndices(featureVector FeatureVector) error {
 	for _, fv := range featureVector {
 		if fv.Index < 1 {
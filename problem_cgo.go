@@ -0,0 +1,259 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+/*
+#cgo CFLAGS: -Wall -Wconversion -O3
+#cgo LDFLAGS: -llinear -lstdc++ -lm
+#include <stddef.h>
+#include "wrap.h"
+*/
+import "C"
+
+import (
+	"runtime"
+	"sync"
+)
+
+// problemMu protects the reference counts of problems.
+var problemMu sync.Mutex
+
+// A Problem is a set of instances and corresponding labels.
+type Problem struct {
+	// The C problem, nil if the problem is closed.
+	problem *C.problem_t
+	insts   []*C.feature_node_t
+	// Problems that are a subset of another problem share its feature
+	// nodes, so the other problem must be kept alive.
+	parent *Problem
+	// The number of open subsets that share the feature nodes.
+	refs int
+	// The error that is returned when the C problem is nil.
+	err error
+	encoders
+}
+
+// NewProblem constructs a new problem instance. Problems are used
+// to store training instances. If memory cannot be allocated for the
+// problem, methods and functions that return an error will return
+// ErrOutOfMemory.
+func NewProblem() *Problem {
+	cProblem, err := newProblem()
+	if err != nil {
+		return &Problem{err: err}
+	}
+
+	problem := &Problem{problem: cProblem}
+	runtime.SetFinalizer(problem, (*Problem).Close)
+	return problem
+}
+
+// Close frees the memory of the problem. After closing, methods and
+// functions that return an error return ErrClosed, other methods
+// behave as if the problem is empty. If the problem is not closed, its
+// memory is freed when the problem is garbage collected.
+func (problem *Problem) Close() error {
+	problemMu.Lock()
+	defer problemMu.Unlock()
+
+	if problem.problem == nil {
+		return nil
+	}
+
+	runtime.SetFinalizer(problem, nil)
+	C.problem_free(problem.problem)
+	problem.problem = nil
+	problem.err = ErrClosed
+	problem.release()
+
+	return nil
+}
+
+// release frees the feature nodes of a closed problem once they are not
+// shared with open subsets anymore. problemMu must be held.
+func (problem *Problem) release() {
+	if problem.problem != nil || problem.refs > 0 {
+		return
+	}
+
+	for _, nodes := range problem.insts {
+		C.nodes_free(nodes)
+	}
+	problem.insts = nil
+
+	if parent := problem.parent; parent != nil {
+		problem.parent = nil
+		parent.refs--
+		parent.release()
+	}
+}
+
+// checkOpen returns ErrClosed if the problem is closed.
+func (problem *Problem) checkOpen() error {
+	return problem.err
+}
+
+// Add adds a training instance to the problem. A weight of zero means
+// the default weight 1. Negative, NaN and infinite weights are rejected.
+// Instance weights other than 1 require a liblinear version with
+// instance weight support, see the package documentation. Otherwise,
+// ErrWeightsUnsupported is returned.
+func (problem *Problem) Add(trainInst TrainingInstance) error {
+	if err := problem.checkOpen(); err != nil {
+		return err
+	}
+
+	if err := verifyFeatureIndices(trainInst.Features); err != nil {
+		return err
+	}
+
+	weight, err := instanceWeight(trainInst)
+	if err != nil {
+		return err
+	}
+
+	if weight != 1 && C.problem_weights_supported() == 0 {
+		return ErrWeightsUnsupported
+	}
+
+	features := sortedFeatureVector(trainInst.Features)
+
+	nodes, err := newNodes(C.size_t(len(features)))
+	if err != nil {
+		return err
+	}
+
+	for idx, val := range features {
+		C.nodes_put(nodes, C.size_t(idx), C.int(val.Index), C.double(val.Value))
+	}
+
+	if C.problem_add_train_inst(problem.problem, nodes, C.double(trainInst.Label),
+		C.double(weight)) == 0 {
+		C.nodes_free(nodes)
+		return ErrOutOfMemory
+	}
+
+	problem.insts = append(problem.insts, nodes)
+
+	return nil
+}
+
+// Bias return the bias term.
+func (problem *Problem) Bias() float64 {
+	if problem.problem == nil {
+		return -1
+	}
+
+	return float64(C.problem_bias(problem.problem))
+}
+
+// SetBias sets the bias term. Setting this value to non-zero amounts to
+// adding an extra feature to each instance with the bias as its value.
+func (problem *Problem) SetBias(bias float64) {
+	if problem.problem == nil {
+		return
+	}
+
+	C.set_problem_bias(problem.problem, C.double(bias))
+}
+
+// trainingProblem returns the C problem that should be passed to
+// liblinear. If the problem has a bias, liblinear expects the bias to
+// be present as an additional feature of every instance. In this case,
+// a copy of the problem with this feature is constructed. The returned
+// function should be called to free the problem when it is not used
+// anymore.
+func (problem *Problem) trainingProblem() (*C.problem_t, func(), error) {
+	if problem.Bias() < 0 {
+		return problem.problem, func() {}, nil
+	}
+
+	biased, err := newBiasedProblem(problem.problem)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return biased, func() {
+		C.problem_with_bias_free(biased)
+	}, nil
+}
+
+// subset returns a problem with the instances at the given indices. The
+// feature nodes are shared with the original problem. The problem
+// should be open.
+func (problem *Problem) subset(indices []int) (*Problem, error) {
+	cProblem, err := newProblem()
+	if err != nil {
+		return nil, err
+	}
+
+	problemMu.Lock()
+	problem.refs++
+	problemMu.Unlock()
+
+	sub := &Problem{problem: cProblem, parent: problem, encoders: problem.encoders}
+	runtime.SetFinalizer(sub, (*Problem).Close)
+
+	C.set_problem_bias(sub.problem, C.problem_bias(problem.problem))
+
+	for _, idx := range indices {
+		if C.problem_add_train_inst(sub.problem,
+			C.nodes_vector_get(problem.problem, C.size_t(idx)),
+			C.get_double_idx(problem.problem.y, C.int(idx)),
+			C.problem_weight(problem.problem, C.size_t(idx))) == 0 {
+			sub.Close()
+			return nil, ErrOutOfMemory
+		}
+	}
+
+	return sub, nil
+}
+
+// targets returns the labels of the instances in the problem.
+func (problem *Problem) targets() []float64 {
+	if problem.problem == nil {
+		return nil
+	}
+
+	targets := make([]float64, int(problem.problem.l))
+	for i := range targets {
+		targets[i] = float64(C.get_double_idx(problem.problem.y, C.int(i)))
+	}
+	return targets
+}
+
+// nFeatures returns the highest feature index of the problem, not
+// counting the bias.
+func (problem *Problem) nFeatures() int {
+	if problem.problem == nil {
+		return 0
+	}
+
+	return int(problem.problem.n)
+}
+
+// Iterate over the training instances in a problem.
+func (problem *Problem) Iterate(fun ProblemIterFunc) {
+	if problem.problem == nil {
+		return
+	}
+
+	for i := 0; i < int(problem.problem.l); i++ {
+		label := float64(C.get_double_idx(problem.problem.y, C.int(i)))
+		weight := float64(C.problem_weight(problem.problem, C.size_t(i)))
+		cNodes := C.nodes_vector_get(problem.problem, C.size_t(i))
+
+		fVals := make(FeatureVector, 0)
+		var j C.size_t
+		for j = 0; C.nodes_get(cNodes, j).index != -1; j++ {
+			cNode := C.nodes_get(cNodes, j)
+			fVals = append(fVals, FeatureValue{int(cNode.index), float64(cNode.value)})
+		}
+
+		if !fun(&TrainingInstance{label, fVals, weight}) {
+			break
+		}
+	}
+}
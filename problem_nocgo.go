@@ -0,0 +1,120 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+//go:build !cgo
+// +build !cgo
+
+package golinear
+
+// A Problem is a set of instances and corresponding labels.
+type Problem struct {
+	insts  []TrainingInstance
+	bias   float64
+	closed bool
+	encoders
+}
+
+// NewProblem constructs a new problem instance. Problems are used
+// to store training instances.
+func NewProblem() *Problem {
+	return &Problem{bias: -1}
+}
+
+// Close releases the instances of the problem. After closing, methods
+// and functions that return an error return ErrClosed, other methods
+// behave as if the problem is empty.
+func (problem *Problem) Close() error {
+	problem.insts = nil
+	problem.bias = -1
+	problem.closed = true
+	return nil
+}
+
+// checkOpen returns ErrClosed if the problem is closed.
+func (problem *Problem) checkOpen() error {
+	if problem.closed {
+		return ErrClosed
+	}
+
+	return nil
+}
+
+// Add adds a training instance to the problem.
+func (problem *Problem) Add(trainInst TrainingInstance) error {
+	if err := problem.checkOpen(); err != nil {
+		return err
+	}
+
+	if err := verifyFeatureIndices(trainInst.Features); err != nil {
+		return err
+	}
+
+	weight, err := instanceWeight(trainInst)
+	if err != nil {
+		return err
+	}
+
+	problem.insts = append(problem.insts, TrainingInstance{trainInst.Label,
+		sortedFeatureVector(trainInst.Features), weight})
+
+	return nil
+}
+
+// Bias return the bias term.
+func (problem *Problem) Bias() float64 {
+	return problem.bias
+}
+
+// SetBias sets the bias term. Setting this value to non-zero amounts to
+// adding an extra feature to each instance with the bias as its value.
+func (problem *Problem) SetBias(bias float64) {
+	if problem.closed {
+		return
+	}
+
+	problem.bias = bias
+}
+
+// subset returns a problem with the instances at the given indices.
+func (problem *Problem) subset(indices []int) (*Problem, error) {
+	sub := &Problem{insts: make([]TrainingInstance, len(indices)), bias: problem.bias,
+		encoders: problem.encoders}
+	for i, idx := range indices {
+		sub.insts[i] = problem.insts[idx]
+	}
+	return sub, nil
+}
+
+// targets returns the labels of the instances in the problem.
+func (problem *Problem) targets() []float64 {
+	targets := make([]float64, len(problem.insts))
+	for i, inst := range problem.insts {
+		targets[i] = inst.Label
+	}
+	return targets
+}
+
+// nFeatures returns the highest feature index of the problem, not
+// counting the bias.
+func (problem *Problem) nFeatures() int {
+	n := 0
+	for _, inst := range problem.insts {
+		if len(inst.Features) > 0 && inst.Features[len(inst.Features)-1].Index > n {
+			n = inst.Features[len(inst.Features)-1].Index
+		}
+	}
+	return n
+}
+
+// Iterate over the training instances in a problem.
+func (problem *Problem) Iterate(fun ProblemIterFunc) {
+	for _, inst := range problem.insts {
+		fVals := make(FeatureVector, len(inst.Features))
+		copy(fVals, inst.Features)
+
+		if !fun(&TrainingInstance{inst.Label, fVals, inst.Weight}) {
+			break
+		}
+	}
+}
@@ -4,7 +4,10 @@
 
 package golinear
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestFromDenseVector(t *testing.T) {
 	fromDense := FromDenseVector([]float64{0.2, 0.1, 0.3, 0.6})
@@ -24,7 +27,7 @@ func TestSortedFeatureVector(t *testing.T) {
 func TestInvalidIndex(t *testing.T) {
 	p := NewProblem()
 	erronous := FeatureVector{{1, 1}, {2, 0.5}, {0, 1}}
-	if err := p.Add(TrainingInstance{0, erronous}); err == nil {
+	if err := p.Add(TrainingInstance{Label: 0, Features: erronous}); err == nil {
 		t.Error("Erronous feature index should be rejected")
 	}
 }
@@ -60,6 +63,87 @@ func TestProblemIterate(t *testing.T) {
 	}
 }
 
+func TestInstanceWeights(t *testing.T) {
+	p := NewProblem()
+
+	for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+		if err := p.Add(TrainingInstance{Label: 0, Features: FeatureVector{{1, 1}}, Weight: w}); err == nil {
+			t.Errorf("Instance weight %f should be rejected", w)
+		}
+	}
+
+	if err := p.Add(TrainingInstance{Label: 0, Features: FeatureVector{{1, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Add(TrainingInstance{Label: 1, Features: FeatureVector{{1, 1}}, Weight: 2.5}); err == ErrWeightsUnsupported {
+		t.Skip(err.Error())
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	var weights []float64
+	p.Iterate(func(instance *TrainingInstance) bool {
+		weights = append(weights, instance.Weight)
+		return true
+	})
+
+	if len(weights) != 2 || weights[0] != 1 || weights[1] != 2.5 {
+		t.Errorf("weights(iterated) = %v, want [1 2.5]", weights)
+	}
+}
+
+func TestProblemClose(t *testing.T) {
+	problem := simpleProblem(t)
+	if err := problem.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing twice is allowed.
+	if err := problem.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if err := problem.Add(simpleInstances()[0]); err != ErrClosed {
+		t.Errorf("Add() = %v, want %v", err, ErrClosed)
+	}
+
+	problem.Iterate(func(*TrainingInstance) bool {
+		t.Error("Closed problem should not have instances")
+		return false
+	})
+
+	if _, err := TrainModel(DefaultParameters(), problem); err != ErrClosed && err != ErrTrainingUnavailable {
+		t.Errorf("TrainModel() = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestProblemCloseShared(t *testing.T) {
+	problem := simpleProblem(t)
+	sub, err := problem.subset([]int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subset shares the feature vectors of the problem, these should
+	// remain valid until the subset is closed.
+	problem.Close()
+
+	instances := simpleInstances()[1:3]
+	idx := 0
+	sub.Iterate(func(instance *TrainingInstance) bool {
+		compareVectors(t, instance.Features, instances[idx].Features, "iterated")
+		idx++
+		return true
+	})
+
+	if idx != 2 {
+		t.Errorf("Subset has %d instances, want 2", idx)
+	}
+
+	sub.Close()
+}
+
 func compareVectors(t *testing.T, candidate, check FeatureVector, candidateName string) {
 	// Sanity check
 	if len(candidate) != len(check) {
@@ -0,0 +1,125 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadProblem reads a problem in the sparse text format that is used by
+// liblinear and libsvm. Each line contains one instance, consisting of
+// the label and index:value pairs:
+//
+//	label index1:value1 index2:value2 ...
+//
+// As in liblinear, the indices of an instance must be in ascending order.
+func ReadProblem(r io.Reader) (*Problem, error) {
+	problem := NewProblem()
+	br := bufio.NewReader(r)
+
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			problem.Close()
+			return nil, err
+		}
+
+		if len(line) == 0 && err == io.EOF {
+			break
+		}
+
+		instance, parseErr := parseInstance(line)
+		if parseErr != nil {
+			problem.Close()
+			return nil, fmt.Errorf("Wrong input format at line %d: %s", lineNo, parseErr.Error())
+		}
+
+		if addErr := problem.Add(instance); addErr != nil {
+			problem.Close()
+			return nil, fmt.Errorf("Wrong input format at line %d: %s", lineNo, addErr.Error())
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return problem, nil
+}
+
+func parseInstance(line string) (TrainingInstance, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return TrainingInstance{}, fmt.Errorf("empty line")
+	}
+
+	label, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return TrainingInstance{}, fmt.Errorf("cannot parse label: %s", fields[0])
+	}
+
+	features := make(FeatureVector, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		sep := strings.IndexByte(field, ':')
+		if sep == -1 {
+			return TrainingInstance{}, fmt.Errorf("feature without value: %s", field)
+		}
+
+		index, err := strconv.Atoi(field[:sep])
+		if err != nil {
+			return TrainingInstance{}, fmt.Errorf("cannot parse feature index: %s", field)
+		}
+
+		if len(features) != 0 && index <= features[len(features)-1].Index {
+			return TrainingInstance{}, fmt.Errorf("feature indices are not in ascending order: %s", field)
+		}
+
+		value, err := strconv.ParseFloat(field[sep+1:], 64)
+		if err != nil {
+			return TrainingInstance{}, fmt.Errorf("cannot parse feature value: %s", field)
+		}
+
+		features = append(features, FeatureValue{index, value})
+	}
+
+	return TrainingInstance{Label: label, Features: features}, nil
+}
+
+// WriteTo writes the problem to a writer in the sparse text format
+// that is used by liblinear and libsvm. See ReadProblem for a
+// description of the format. The bias is not written. The number of
+// bytes written is returned.
+func (problem *Problem) WriteTo(w io.Writer) (int64, error) {
+	if err := problem.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	var err error
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		bw.WriteString(strconv.FormatFloat(instance.Label, 'g', -1, 64))
+		for _, f := range instance.Features {
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.Itoa(f.Index))
+			bw.WriteByte(':')
+			bw.WriteString(strconv.FormatFloat(f.Value, 'g', -1, 64))
+		}
+		_, err = bw.WriteString("\n")
+
+		return err == nil
+	})
+
+	if err == nil {
+		err = bw.Flush()
+	}
+
+	return cw.n, err
+}
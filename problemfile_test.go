@@ -0,0 +1,100 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const simpleProblemText = `0 1:1 2:1 3:1
+0 2:1
+1 1:1 3:1 4:1 5:1
+1 4:1 5:1
+`
+
+func TestReadProblem(t *testing.T) {
+	problem, err := ReadProblem(strings.NewReader(simpleProblemText))
+	if err != nil {
+		t.Fatal("Could not read problem: " + err.Error())
+	}
+
+	instances := simpleInstances()
+
+	idx := 0
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		check := instances[idx]
+
+		// Zero values are not stored in the text format.
+		var nonZero FeatureVector
+		for _, f := range check.Features {
+			if f.Value != 0 {
+				nonZero = append(nonZero, f)
+			}
+		}
+
+		compareVectors(t, instance.Features, nonZero, "read")
+
+		if instance.Label != check.Label {
+			t.Errorf("label(read) = %f, want %f", instance.Label, check.Label)
+		}
+
+		idx++
+
+		return true
+	})
+
+	if idx != len(instances) {
+		t.Errorf("len(read) = %d, want %d", idx, len(instances))
+	}
+}
+
+func TestReadProblemErrors(t *testing.T) {
+	invalid := map[string]string{
+		"1 1:1\n\n-1 2:1\n":  "line 2",
+		"1 1:1\nx 1:1\n":     "line 2",
+		"1 1:1 2\n":          "line 1",
+		"1 1:1\n-1 2:1 1:1":  "line 2",
+		"1 1:1\n-1 0:1\n":    "line 2",
+		"1 1:1\n-1 a:1\n":    "line 2",
+		"1 1:1\n1 2:1\n1 3:": "line 3",
+	}
+
+	for text, line := range invalid {
+		_, err := ReadProblem(strings.NewReader(text))
+		if err == nil {
+			t.Errorf("Invalid problem should be rejected: %q", text)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("Error %q for %q should mention %s", err.Error(), text, line)
+		}
+	}
+}
+
+func TestProblemWriteTo(t *testing.T) {
+	text := "-1 1:0.10000000000000001 3:-2.5\n2 4:1e-10\n1\n"
+
+	problem, err := ReadProblem(strings.NewReader(text))
+	if err != nil {
+		t.Fatal("Could not read problem: " + err.Error())
+	}
+
+	var buf bytes.Buffer
+	n, err := problem.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("Could not write problem: " + err.Error())
+	}
+
+	if want := "-1 1:0.1 3:-2.5\n2 4:1e-10\n1\n"; buf.String() != want {
+		t.Errorf("WriteTo() = %q, want %q", buf.String(), want)
+	}
+
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, want %d", n, buf.Len())
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// A Scaler scales the values of features. Linear solvers are sensitive
+// to the scale of features, so it is often beneficial to scale features
+// to a common range. A scaler is fitted on the instances of a problem and
+// should be used to scale the instances for training as well as for
+// prediction.
+//
+// Min-max scalers are compatible with the svm-scale tool of liblinear and
+// libsvm: their ranges can be read from and written to svm-scale range
+// files.
+//
+// Feature values that are zero are not stored in feature vectors. When a
+// feature is scaled such that zero maps to a non-zero value, scaling adds
+// the feature to every vector. Sparsity is preserved when zero maps to
+// zero, e.g. for non-negative features that are scaled to [0, 1], or when
+// standard scaling is used without centering.
+type Scaler struct {
+	standard bool
+
+	// Min-max scaling: the target range and the per-feature ranges.
+	lower, upper           float64
+	featureMin, featureMax []float64
+
+	// Standard scaling: the per-feature means and standard deviations.
+	center    bool
+	mean, std []float64
+
+	// Label range of svm-scale range files, which is preserved but not
+	// used.
+	yRange []float64
+
+	// Scaled values of zero, for features where zero is not mapped to
+	// zero.
+	zeroIndices []int
+	zeroValues  []float64
+}
+
+// NewMinMaxScaler creates a scaler that maps the range of each feature in
+// the problem to [lower, upper], as svm-scale does. svm-scale uses the
+// range [-1, 1] by default. Features that have the same value in every
+// instance are removed by the scaler.
+func NewMinMaxScaler(problem *Problem, lower, upper float64) (*Scaler, error) {
+	if !(lower < upper) {
+		return nil, fmt.Errorf("The lower bound (%f) should be smaller than the upper bound (%f)",
+			lower, upper)
+	}
+
+	stats, err := featureStatistics(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scaler{
+		lower:      lower,
+		upper:      upper,
+		featureMin: stats.min,
+		featureMax: stats.max,
+	}
+	s.findZeros()
+
+	return s, nil
+}
+
+// NewStandardScaler creates a scaler that divides each feature in the
+// problem by its standard deviation. If center is true, the mean of the
+// feature is subtracted first. Centering makes all feature vectors dense.
+// Features that have the same value in every instance are removed by the
+// scaler.
+func NewStandardScaler(problem *Problem, center bool) (*Scaler, error) {
+	stats, err := featureStatistics(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scaler{
+		standard: true,
+		center:   center,
+		mean:     stats.mean,
+		std:      stats.std,
+	}
+	s.findZeros()
+
+	return s, nil
+}
+
+// featureStats contains the statistics of each feature. The statistics
+// of feature i are stored at index i, index 0 is unused.
+type featureStats struct {
+	min, max, mean, std []float64
+}
+
+// featureStatistics computes feature statistics, counting features that
+// are not stored in a vector as zero.
+func featureStatistics(problem *Problem) (featureStats, error) {
+	if err := problem.checkOpen(); err != nil {
+		return featureStats{}, err
+	}
+
+	n := problem.nFeatures() + 1
+	stats := featureStats{
+		min:  make([]float64, n),
+		max:  make([]float64, n),
+		mean: make([]float64, n),
+		std:  make([]float64, n),
+	}
+
+	for i := 1; i < n; i++ {
+		stats.min[i] = math.Inf(1)
+		stats.max[i] = math.Inf(-1)
+	}
+
+	counts := make([]int, n)
+	sumSquares := make([]float64, n)
+	nInstances := 0
+
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		nInstances++
+		for _, fv := range instance.Features {
+			stats.min[fv.Index] = math.Min(stats.min[fv.Index], fv.Value)
+			stats.max[fv.Index] = math.Max(stats.max[fv.Index], fv.Value)
+			stats.mean[fv.Index] += fv.Value
+			sumSquares[fv.Index] += fv.Value * fv.Value
+			counts[fv.Index]++
+		}
+		return true
+	})
+
+	for i := 1; i < n; i++ {
+		// Implicit zeros.
+		if counts[i] < nInstances {
+			stats.min[i] = math.Min(stats.min[i], 0)
+			stats.max[i] = math.Max(stats.max[i], 0)
+		}
+
+		if nInstances > 0 {
+			stats.mean[i] /= float64(nInstances)
+			variance := sumSquares[i]/float64(nInstances) - stats.mean[i]*stats.mean[i]
+			stats.std[i] = math.Sqrt(math.Max(variance, 0))
+		}
+	}
+
+	return stats, nil
+}
+
+// findZeros finds the features for which zero is not mapped to zero.
+func (s *Scaler) findZeros() {
+	s.zeroIndices = nil
+	s.zeroValues = nil
+
+	for index := 1; index < s.nFeatures()+1; index++ {
+		if v, ok := s.scaleValue(index, 0); ok && v != 0 {
+			s.zeroIndices = append(s.zeroIndices, index)
+			s.zeroValues = append(s.zeroValues, v)
+		}
+	}
+}
+
+// nFeatures returns the highest feature index that the scaler knows.
+func (s *Scaler) nFeatures() int {
+	if s.standard {
+		return len(s.std) - 1
+	}
+
+	return len(s.featureMin) - 1
+}
+
+// scaleValue scales the value of a feature. ok is false when the
+// feature is removed by the scaler.
+func (s *Scaler) scaleValue(index int, value float64) (scaled float64, ok bool) {
+	if index < 1 || index > s.nFeatures() {
+		return 0, false
+	}
+
+	if s.standard {
+		if s.std[index] == 0 {
+			return 0, false
+		}
+
+		if s.center {
+			value -= s.mean[index]
+		}
+
+		return value / s.std[index], true
+	}
+
+	// Port of svm-scale's output function.
+	min, max := s.featureMin[index], s.featureMax[index]
+	switch {
+	case max == min:
+		return 0, false
+	case value == min:
+		return s.lower, true
+	case value == max:
+		return s.upper, true
+	default:
+		return s.lower + (s.upper-s.lower)*(value-min)/(max-min), true
+	}
+}
+
+// Scale returns a scaled copy of a feature vector, sorted by index.
+// Features that were not seen when the scaler was fitted and features
+// that have the same value in every instance are removed. Like svm-scale,
+// features that are scaled to zero are removed.
+func (s *Scaler) Scale(features FeatureVector) FeatureVector {
+	sorted := sortedFeatureVector(features)
+
+	scaled := make(FeatureVector, 0, len(sorted)+len(s.zeroIndices))
+	zeroIdx := 0
+	for _, fv := range sorted {
+		// Add scaled zeros of features that are not in the vector.
+		for zeroIdx < len(s.zeroIndices) && s.zeroIndices[zeroIdx] <= fv.Index {
+			if s.zeroIndices[zeroIdx] < fv.Index {
+				scaled = append(scaled, FeatureValue{s.zeroIndices[zeroIdx], s.zeroValues[zeroIdx]})
+			}
+			zeroIdx++
+		}
+
+		if v, ok := s.scaleValue(fv.Index, fv.Value); ok && v != 0 {
+			scaled = append(scaled, FeatureValue{fv.Index, v})
+		}
+	}
+
+	for ; zeroIdx < len(s.zeroIndices); zeroIdx++ {
+		scaled = append(scaled, FeatureValue{s.zeroIndices[zeroIdx], s.zeroValues[zeroIdx]})
+	}
+
+	return scaled
+}
+
+// ScaleProblem returns a problem with the scaled instances of a problem.
+// The bias, dictionaries, and feature hasher of the problem are copied.
+func (s *Scaler) ScaleProblem(problem *Problem) (*Problem, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	scaled := NewProblem()
+	if err := scaled.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	scaled.SetBias(problem.Bias())
+	scaled.encoders = problem.encoders
+
+	var err error
+	problem.Iterate(func(instance *TrainingInstance) bool {
+		err = scaled.Add(TrainingInstance{
+			Label:    instance.Label,
+			Features: s.Scale(instance.Features),
+			Weight:   instance.Weight,
+		})
+		return err == nil
+	})
+
+	if err != nil {
+		scaled.Close()
+		return nil, err
+	}
+
+	return scaled, nil
+}
+
+// LoadScaler loads a min-max scaler from an svm-scale range file.
+func LoadScaler(filename string) (*Scaler, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := ReadScaler(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read range file %s: %s", filename, err.Error())
+	}
+
+	return s, nil
+}
+
+// ReadScaler reads a min-max scaler from a reader in the svm-scale range
+// file format.
+func ReadScaler(r io.Reader) (*Scaler, error) {
+	ws := newWordScanner(r, "range file")
+	s := &Scaler{}
+
+	section, err := ws.next("x or y")
+	if err != nil {
+		return nil, err
+	}
+
+	if section == "y" {
+		// y_lower y_upper y_min y_max
+		s.yRange = make([]float64, 4)
+		for i := range s.yRange {
+			if s.yRange[i], err = ws.nextFloat("label range"); err != nil {
+				return nil, err
+			}
+		}
+
+		if section, err = ws.next("x"); err != nil {
+			return nil, err
+		}
+	}
+
+	if section != "x" {
+		return nil, fmt.Errorf("Expected x, got: %s", section)
+	}
+
+	if s.lower, err = ws.nextFloat("lower bound"); err != nil {
+		return nil, err
+	}
+	if s.upper, err = ws.nextFloat("upper bound"); err != nil {
+		return nil, err
+	}
+
+	type featureRange struct {
+		index    int
+		min, max float64
+	}
+
+	var ranges []featureRange
+	maxIndex := 0
+	for {
+		field, ok, err := ws.scan()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 1 {
+			return nil, fmt.Errorf("Cannot parse feature index: %s", field)
+		}
+
+		min, err := ws.nextFloat("feature minimum")
+		if err != nil {
+			return nil, err
+		}
+		max, err := ws.nextFloat("feature maximum")
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, featureRange{index, min, max})
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+
+	// Features that are not in the file have an empty range and are
+	// removed.
+	s.featureMin = make([]float64, maxIndex+1)
+	s.featureMax = make([]float64, maxIndex+1)
+	for _, r := range ranges {
+		s.featureMin[r.index] = r.min
+		s.featureMax[r.index] = r.max
+	}
+
+	s.findZeros()
+
+	return s, nil
+}
+
+// Save the scaler to an svm-scale range file. Only min-max scalers can
+// be saved.
+func (s *Scaler) Save(filename string) error {
+	if s.standard {
+		return errStandardRange
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return errors.New("Could not save scaler to file: " + filename)
+	}
+
+	if _, err := s.WriteTo(f); err != nil {
+		f.Close()
+		return errors.New("Could not save scaler to file: " + filename)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.New("Could not save scaler to file: " + filename)
+	}
+
+	return nil
+}
+
+var errStandardRange = errors.New("Standard scalers cannot be written as svm-scale range files")
+
+// WriteTo writes the scaler to a writer in the svm-scale range file
+// format. The number of bytes written is returned. Only min-max scalers
+// can be written.
+func (s *Scaler) WriteTo(w io.Writer) (int64, error) {
+	if s.standard {
+		return 0, errStandardRange
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	if s.yRange != nil {
+		fmt.Fprintf(bw, "y\n%s %s\n%s %s\n", formatFloat(s.yRange[0]), formatFloat(s.yRange[1]),
+			formatFloat(s.yRange[2]), formatFloat(s.yRange[3]))
+	}
+
+	fmt.Fprintf(bw, "x\n%s %s\n", formatFloat(s.lower), formatFloat(s.upper))
+
+	// Like svm-scale, features with an empty range are not written.
+	for index := 1; index < len(s.featureMin); index++ {
+		if s.featureMin[index] != s.featureMax[index] {
+			fmt.Fprintf(bw, "%d %s %s\n", index, formatFloat(s.featureMin[index]),
+				formatFloat(s.featureMax[index]))
+		}
+	}
+
+	err := bw.Flush()
+
+	return cw.n, err
+}
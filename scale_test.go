@@ -0,0 +1,163 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func scaleProblem() *Problem {
+	problem := NewProblem()
+	problem.Add(TrainingInstance{Label: 1, Features: FeatureVector{{1, 1}, {2, 4}}})
+	problem.Add(TrainingInstance{Label: 2, Features: FeatureVector{{1, 3}}})
+	problem.Add(TrainingInstance{Label: 1, Features: FeatureVector{{1, 2}, {2, 2}}})
+	return problem
+}
+
+func checkFeatureVector(t *testing.T, name string, fv, want FeatureVector) {
+	if len(fv) != len(want) {
+		t.Errorf("%s = %v, want %v", name, fv, want)
+		return
+	}
+
+	for i := range want {
+		if fv[i].Index != want[i].Index || math.Abs(fv[i].Value-want[i].Value) > 1e-12 {
+			t.Errorf("%s = %v, want %v", name, fv, want)
+			return
+		}
+	}
+}
+
+func TestMinMaxScaler(t *testing.T) {
+	s, err := NewMinMaxScaler(scaleProblem(), -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Feature 1 has the range [1, 3], feature 2 has the range [0, 4]
+	// because of the implicit zero in the second instance. Zero values
+	// are removed, implicit zeros of feature 2 are scaled to -1.
+	checkFeatureVector(t, "Scale({1:1, 2:4})", s.Scale(FeatureVector{{1, 1}, {2, 4}}), FeatureVector{{1, -1}, {2, 1}})
+	checkFeatureVector(t, "Scale({1:2})", s.Scale(FeatureVector{{1, 2}}), FeatureVector{{2, -1}})
+	checkFeatureVector(t, "Scale({2:3, 1:1.5})", s.Scale(FeatureVector{{2, 3}, {1, 1.5}}), FeatureVector{{1, -0.5}, {2, 0.5}})
+
+	// Unknown features are removed. Feature 1 has no implicit zeros, like
+	// svm-scale, its zero is scaled as well.
+	checkFeatureVector(t, "Scale({3:5})", s.Scale(FeatureVector{{3, 5}}), FeatureVector{{1, -2}, {2, -1}})
+
+	if _, err := NewMinMaxScaler(scaleProblem(), 1, 1); err == nil {
+		t.Error("An empty target range should be rejected")
+	}
+}
+
+func TestMinMaxScalerSparse(t *testing.T) {
+	s, err := NewMinMaxScaler(scaleProblem(), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Feature 2 has zero as its minimum, so zeros remain zero.
+	checkFeatureVector(t, "Scale({1:2})", s.Scale(FeatureVector{{1, 2}}), FeatureVector{{1, 0.5}})
+	checkFeatureVector(t, "Scale({1:1})", s.Scale(FeatureVector{{1, 1}}), FeatureVector{})
+}
+
+func TestStandardScaler(t *testing.T) {
+	s, err := NewStandardScaler(scaleProblem(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Feature 1: mean 2, variance 2/3. Feature 2: mean 2, variance 8/3.
+	checkFeatureVector(t, "Scale({1:2, 2:2})", s.Scale(FeatureVector{{1, 2}, {2, 2}}),
+		FeatureVector{{1, 2 / math.Sqrt(2./3.)}, {2, 2 / math.Sqrt(8./3.)}})
+	checkFeatureVector(t, "Scale({})", s.Scale(nil), FeatureVector{})
+
+	s, err = NewStandardScaler(scaleProblem(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFeatureVector(t, "Scale({1:3})", s.Scale(FeatureVector{{1, 3}}),
+		FeatureVector{{1, 1 / math.Sqrt(2./3.)}, {2, -2 / math.Sqrt(8./3.)}})
+
+	if _, err := s.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Error("Standard scalers should not be written as range files")
+	}
+}
+
+func TestScaleProblem(t *testing.T) {
+	s, err := NewMinMaxScaler(scaleProblem(), -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scaled, err := s.ScaleProblem(scaleProblem())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TrainingInstance{
+		{1, FeatureVector{{1, -1}, {2, 1}}, 1},
+		{2, FeatureVector{{1, 1}, {2, -1}}, 1},
+		{1, FeatureVector{}, 1},
+	}
+
+	i := 0
+	scaled.Iterate(func(instance *TrainingInstance) bool {
+		if instance.Label != want[i].Label {
+			t.Errorf("Label of instance %d = %f, want %f", i, instance.Label, want[i].Label)
+		}
+		checkFeatureVector(t, "Features", instance.Features, want[i].Features)
+		i++
+		return true
+	})
+
+	if i != len(want) {
+		t.Errorf("Scaled problem has %d instances, want %d", i, len(want))
+	}
+}
+
+func TestScalerRangeFile(t *testing.T) {
+	s, err := NewMinMaxScaler(scaleProblem(), -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "x\n-1 1\n1 1 3\n2 0 4\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo() wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	// A range file as written by svm-scale with label scaling, where
+	// feature 2 is constant.
+	rangeFile := "y\n0 1\n1 2\nx\n0 1\n1 1 3\n3 -2 2\n"
+	s, err = ReadScaler(strings.NewReader(rangeFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFeatureVector(t, "Scale({1:2, 2:7, 3:1})", s.Scale(FeatureVector{{1, 2}, {2, 7}, {3, 1}}),
+		FeatureVector{{1, 0.5}, {3, 0.75}})
+
+	buf.Reset()
+	if _, err := s.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != rangeFile {
+		t.Errorf("WriteTo() wrote:\n%s\nwant:\n%s", buf.String(), rangeFile)
+	}
+
+	if _, err := ReadScaler(strings.NewReader("x\n0 1\n1 1\n")); err == nil {
+		t.Error("Truncated range files should be rejected")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import "errors"
+
+// ParameterSearchResult is the result of a search for the parameters
+// with the best cross-validation score.
+type ParameterSearchResult struct {
+	// The best cost of constraints violation.
+	Cost float64
+	// The best sensitivity of the loss, for support vector regression.
+	P float64
+	// The cross-validation score of the best parameters: the accuracy
+	// for classification and the mean squared error for regression.
+	Score float64
+}
+
+// GridSearch evaluates each of the candidate parameters using
+// cross-validation over the given folds (see CrossValidationFolds) and
+// returns the best candidate with its score. For classification, the
+// score is the accuracy and the candidate with the highest accuracy is
+// the best. For regression, the score is the mean squared error and the
+// candidate with the lowest error is the best. Classification and
+// regression solvers cannot be mixed. If multiple candidates have the
+// best score, the first is returned.
+//
+// In contrast to FindParameters, any parameter can be searched.
+func GridSearch(problem *Problem, candidates []Parameters, folds []int) (Parameters, float64, error) {
+	if len(candidates) == 0 {
+		return Parameters{}, 0, errors.New("Grid search requires at least one candidate")
+	}
+
+	regression := isRegressionSolver(candidates[0].SolverType.solverType)
+	for _, candidate := range candidates[1:] {
+		if isRegressionSolver(candidate.SolverType.solverType) != regression {
+			return Parameters{}, 0, errors.New("Grid search cannot mix classification and regression solvers")
+		}
+	}
+
+	var best Parameters
+	var bestScore float64
+	for idx, candidate := range candidates {
+		predictions, err := CrossValidationFolds(problem, candidate, folds)
+		if err != nil {
+			return Parameters{}, 0, err
+		}
+
+		var score float64
+		if regression {
+			eval, err := EvaluateRegression(problem, predictions)
+			if err != nil {
+				return Parameters{}, 0, err
+			}
+			score = eval.MeanSquaredError
+		} else {
+			eval, err := EvaluateClassification(problem, predictions)
+			if err != nil {
+				return Parameters{}, 0, err
+			}
+			score = eval.Accuracy
+		}
+
+		if idx == 0 || (regression && score < bestScore) || (!regression && score > bestScore) {
+			best, bestScore = candidate, score
+		}
+	}
+
+	return best, bestScore, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFindParameters(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RLogisticRegressionDefault()
+
+	result, err := FindParameters(problem, param, 5, -1, -1)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatalf("Could not find parameters: %s", err.Error())
+	}
+
+	if result.Cost <= 0 {
+		t.Errorf("Cost = %f, want a positive cost", result.Cost)
+	}
+
+	if result.Score < 0 || result.Score > 1 {
+		t.Errorf("Score = %f, want an accuracy", result.Score)
+	}
+}
+
+func TestFindParametersSvr(t *testing.T) {
+	problem := NewProblem()
+	for i := 0; i < 10; i++ {
+		x := float64(i)
+		problem.Add(TrainingInstance{Label: 2 * x, Features: FeatureVector{{1, x}}})
+	}
+
+	param := DefaultParameters()
+	param.SolverType = NewL2RL2LossSvRegressionDefault()
+
+	// Supported by liblinear 2.30 and later, older versions should
+	// report that the search is unsupported.
+	result, err := FindParameters(problem, param, 5, -1, -1)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatalf("Could not find parameters: %s", err.Error())
+	}
+
+	if result.Cost <= 0 {
+		t.Errorf("Cost = %f, want a positive cost", result.Cost)
+	}
+}
+
+func TestFindParametersUnsupportedSolver(t *testing.T) {
+	if _, err := FindParameters(tenInstanceProblem(t), DefaultParameters(), 5, -1, -1); err == nil {
+		t.Error("Parameter search with an unsupported solver should fail")
+	}
+}
+
+func TestGridSearch(t *testing.T) {
+	problem := tenInstanceProblem(t)
+
+	var candidates []Parameters
+	for _, cost := range []float64{0.5, 1, 2} {
+		param := DefaultParameters()
+		param.Cost = cost
+		candidates = append(candidates, param)
+	}
+
+	folds, err := StratifiedFolds(problem, 5, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best, score, err := GridSearch(problem, candidates, folds)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
+	if err != nil {
+		t.Fatalf("Could not perform grid search: %s", err.Error())
+	}
+
+	// All candidates classify the problem perfectly, so the first should
+	// be returned.
+	if best.Cost != 0.5 || score != 1 {
+		t.Errorf("GridSearch() = (%f, %f), want (0.5, 1)", best.Cost, score)
+	}
+}
+
+func TestGridSearchMixedSolvers(t *testing.T) {
+	regression := DefaultParameters()
+	regression.SolverType = NewL2RL2LossSvRegressionDefault()
+
+	candidates := []Parameters{DefaultParameters(), regression}
+	if _, _, err := GridSearch(tenInstanceProblem(t), candidates, nil); err == nil {
+		t.Error("Grid search with classification and regression solvers should fail")
+	}
+}
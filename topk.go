@@ -0,0 +1,96 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+)
+
+// LabelScore is a label with its score.
+type LabelScore struct {
+	Label int
+	Score float64
+}
+
+// PredictTopK returns the k labels with the highest scores for an
+// instance, in descending order of score. If the model provides
+// probability estimates, the scores are probabilities, otherwise the
+// scores are decision values. For two-class models, the decision value of
+// the second label is the negated decision value of the first label. If k
+// exceeds the number of labels, all labels are returned.
+func (model *Model) PredictTopK(nodes []FeatureValue, k int) ([]LabelScore, error) {
+	if model.model == nil {
+		return nil, ErrClosed
+	}
+
+	if model.model.isRegression() {
+		return nil, errors.New("Top-k prediction requires a classification model")
+	}
+
+	if k < 0 {
+		return nil, errors.New("k should not be negative")
+	}
+
+	m := model.model
+	scores := make([]float64, m.nrClass)
+	if model.SupportsProbability() {
+		var err error
+		if _, scores, err = model.PredictProbabilitySlice(nodes); err != nil {
+			return nil, err
+		}
+	} else {
+		m.predictValues(nodes, scores)
+		if m.nrClass == 2 {
+			scores[1] = -scores[0]
+		}
+	}
+
+	return topK(model.Labels(), scores, k), nil
+}
+
+// topK selects the k labels with the highest scores using a min-heap of
+// size k, so that selection is O(n log k) for n labels.
+func topK(labels []int, scores []float64, k int) []LabelScore {
+	if k > len(labels) {
+		k = len(labels)
+	}
+
+	h := make(labelScoreHeap, 0, k)
+	for i, label := range labels {
+		switch {
+		case len(h) < k:
+			heap.Push(&h, LabelScore{label, scores[i]})
+		case k > 0 && scores[i] > h[0].Score:
+			h[0] = LabelScore{label, scores[i]}
+			heap.Fix(&h, 0)
+		}
+	}
+
+	sort.Slice(h, func(i, j int) bool {
+		return h[i].Score > h[j].Score
+	})
+
+	return h
+}
+
+// labelScoreHeap is a min-heap of label scores.
+type labelScoreHeap []LabelScore
+
+func (h labelScoreHeap) Len() int           { return len(h) }
+func (h labelScoreHeap) Less(i, j int) bool { return h[i].Score < h[j].Score }
+func (h labelScoreHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *labelScoreHeap) Push(x interface{}) {
+	*h = append(*h, x.(LabelScore))
+}
+
+func (h *labelScoreHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
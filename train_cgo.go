@@ -0,0 +1,206 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+/*
+#include <stdlib.h>
+#include "wrap.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// TrainModel trains an SVM using the given parameters and problem.
+func TrainModel(param Parameters, problem *Problem) (*Model, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	cProblem, freeProblem, err := problem.trainingProblem()
+	if err != nil {
+		return nil, err
+	}
+	defer freeProblem()
+
+	cParam, err := toCParameter(param, problem, cProblem)
+	if err != nil {
+		return nil, err
+	}
+	defer freeParameter(cParam)
+
+	// Check validity of the parameters.
+	r := C.check_parameter_wrap(cProblem, cParam)
+	if r != nil {
+		msg := C.GoString(r)
+		return nil, errors.New(msg)
+	}
+
+	printHandle, freePrintHandle := newPrintHandle(param.Print)
+	defer freePrintHandle()
+
+	cmodel := C.train_wrap(cProblem, cParam, printHandle)
+	defer C.free_and_destroy_model_wrap(cmodel)
+
+	return &Model{model: fromCModel(cmodel), encoders: problem.encoders.clone()}, nil
+}
+
+// TrainModelFrom trains a model like TrainModel, but starts the solver
+// at the weights of an existing model. This can reduce the training time
+// considerably when the problem is similar to the problem of the initial
+// model, e.g. when the problem has grown since the initial model was
+// trained.
+//
+// The initial model should be trained with the same solver and should
+// have the same labels as the problem. A bias should be used either in
+// both the problem and the initial model or in neither. Features that
+// do not occur in the initial model start with a zero weight. Warm
+// starts are supported by the L2R_LR, L2R_L2LOSS_SVC, and L2R_L2LOSS_SVR
+// solvers. The InitialSolution of param is not used.
+func TrainModelFrom(param Parameters, problem *Problem, initial *Model) (*Model, error) {
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if initial.model == nil {
+		return nil, ErrClosed
+	}
+
+	initSol, err := warmStartSolution(param, problem, initial.model)
+	if err != nil {
+		return nil, err
+	}
+
+	param.InitialSolution = initSol
+
+	return TrainModel(param, problem)
+}
+
+func fromCModel(cmodel *C.model_t) *linearModel {
+	m := &linearModel{
+		solverType: int(cmodel.param.solver_type),
+		nrClass:    int(cmodel.nr_class),
+		nrFeature:  int(cmodel.nr_feature),
+		bias:       float64(cmodel.bias),
+		rho:        float64(C.model_rho(cmodel)),
+	}
+
+	if cmodel.label != nil {
+		m.labels = make([]int, m.nrClass)
+		for i := range m.labels {
+			m.labels[i] = int(C.get_int_idx(cmodel.label, C.int(i)))
+		}
+	}
+
+	m.w = make([]float64, m.wSize()*m.nrWeightVectors())
+	for i := range m.w {
+		m.w[i] = float64(C.get_double_idx(cmodel.w, C.int(i)))
+	}
+
+	return m
+}
+
+// toCParameter converts parameters to liblinear's representation. An
+// error that wraps ErrUnsupported is returned when a parameter is not
+// supported by the linked liblinear version.
+func toCParameter(param Parameters, problem *Problem, cProblem *C.problem_t) (*C.parameter_t, error) {
+	cParam, err := newParameter()
+	if err != nil {
+		return nil, err
+	}
+
+	cParam.solver_type = C.int(param.SolverType.solverType)
+	cParam.eps = C.double(param.SolverType.epsilon)
+	cParam.C = C.double(param.Cost)
+	cParam.p = C.double(param.P)
+
+	nu := param.Nu
+	if param.SolverType.nu != 0 {
+		nu = param.SolverType.nu
+	}
+
+	if nu != 0 && C.parameter_set_nu(cParam, C.double(nu)) == 0 {
+		freeParameter(cParam)
+		return nil, fmt.Errorf("%w: nu", ErrUnsupported)
+	}
+
+	if param.UnregularizedBias && C.parameter_set_regularize_bias(cParam, 0) == 0 {
+		freeParameter(cParam)
+		return nil, fmt.Errorf("%w: unregularized bias", ErrUnsupported)
+	}
+
+	if param.InitialSolution != nil {
+		initSol, err := newInitialSolution(param.InitialSolution, problem, cProblem)
+		if err != nil {
+			freeParameter(cParam)
+			return nil, err
+		}
+
+		if C.parameter_set_init_sol(cParam, initSol) == 0 {
+			C.free(unsafe.Pointer(initSol))
+			freeParameter(cParam)
+			return nil, fmt.Errorf("%w: initial solution", ErrUnsupported)
+		}
+	}
+
+	// Copy relative costs into C structure.
+	n := len(param.RelCosts)
+	if n > 0 {
+		cParam.nr_weight = C.int(n)
+		if cParam.weight_label, err = newLabels(C.int(n)); err != nil {
+			freeParameter(cParam)
+			return nil, err
+		}
+		if cParam.weight, err = newDouble(C.size_t(n)); err != nil {
+			freeParameter(cParam)
+			return nil, err
+		}
+		for i, weight := range param.RelCosts {
+			C.set_int_idx(cParam.weight_label, C.int(i), C.int(weight.Label))
+			C.set_double_idx(cParam.weight, C.int(i), C.double(weight.Value))
+		}
+	}
+
+	// Set the number of threads to use by OpenMP.
+	C.parameter_set_nthreads(cParam, C.int(param.NThreads))
+
+	return cParam, nil
+}
+
+func freeParameter(cParam *C.parameter_t) {
+	C.parameter_free(cParam)
+	C.destroy_param_wrap(cParam)
+	C.free(unsafe.Pointer(cParam))
+}
+
+// newInitialSolution copies an initial solution to a C array. liblinear
+// reads a weight for every feature of the training problem and, for
+// multi-class problems, every class. The array is padded with zeros to
+// avoid reads past the initial solution.
+func newInitialSolution(initSol []float64, problem *Problem, cProblem *C.problem_t) (*C.double, error) {
+	labels := make(map[float64]struct{})
+	for _, label := range problem.targets() {
+		labels[label] = struct{}{}
+	}
+
+	n := int(cProblem.n) * len(labels)
+	if n < len(initSol) {
+		n = len(initSol)
+	}
+
+	cInitSol, err := newDouble(C.size_t(n))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, w := range initSol {
+		C.set_double_idx(cInitSol, C.int(i), C.double(w))
+	}
+
+	return cInitSol, nil
+}
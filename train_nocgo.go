@@ -0,0 +1,36 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+//go:build !cgo
+// +build !cgo
+
+package golinear
+
+// TrainModel trains an SVM using the given parameters and problem.
+// Training requires liblinear, so without cgo ErrTrainingUnavailable
+// is returned.
+func TrainModel(param Parameters, problem *Problem) (*Model, error) {
+	return nil, ErrTrainingUnavailable
+}
+
+// TrainModelFrom trains a model, starting at the weights of an existing
+// model. Training requires liblinear, so without cgo
+// ErrTrainingUnavailable is returned.
+func TrainModelFrom(param Parameters, problem *Problem, initial *Model) (*Model, error) {
+	return nil, ErrTrainingUnavailable
+}
+
+// CrossValidation separates the problem in folds. Each fold is sequentially
+// evaluated using the model trained with the remaining folds. Training
+// requires liblinear, so without cgo ErrTrainingUnavailable is returned.
+func CrossValidation(problem *Problem, param Parameters, nFolds uint) ([]float64, error) {
+	return nil, ErrTrainingUnavailable
+}
+
+// FindParameters uses liblinear's parameter search to find the best
+// cost of constraint violation. Parameter search requires liblinear, so
+// without cgo ErrTrainingUnavailable is returned.
+func FindParameters(problem *Problem, param Parameters, nFolds uint, startC, startP float64) (ParameterSearchResult, error) {
+	return ParameterSearchResult{}, ErrTrainingUnavailable
+}
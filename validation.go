@@ -12,30 +12,49 @@ import "C"
 
 import (
 	"errors"
+	"fmt"
 	"unsafe"
 )
 
 // CrossValidation separates the problem in folds. Each fold is sequentially
 // evaluated using the model trained with the remaining folds. The slice that
-// is returned contains the predicted instance classes.
+// is returned contains the predicted instance classes. The folds are
+// chosen randomly by liblinear, CrossValidationFolds can be used to
+// control the assignment of instances to folds.
 func CrossValidation(problem *Problem, param Parameters, nFolds uint) ([]float64, error) {
-	cParam := toCParameter(param)
-	defer func() {
-		C.destroy_param_wrap(cParam)
-		C.free(unsafe.Pointer(cParam))
-	}()
+	if err := problem.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	cProblem, freeProblem, err := problem.trainingProblem()
+	if err != nil {
+		return nil, err
+	}
+	defer freeProblem()
 
-	r := C.check_parameter_wrap(problem.problem, cParam)
+	cParam, err := toCParameter(param, problem, cProblem)
+	if err != nil {
+		return nil, err
+	}
+	defer freeParameter(cParam)
+
+	r := C.check_parameter_wrap(cProblem, cParam)
 	if r != nil {
 		msg := C.GoString(r)
 		return nil, errors.New(msg)
 	}
 
 	nInstances := uint(problem.problem.l)
-	target := newDouble(C.size_t(nInstances))
+	target, err := newDouble(C.size_t(nInstances))
+	if err != nil {
+		return nil, err
+	}
 	defer C.free(unsafe.Pointer(target))
 
-	C.cross_validation_wrap(problem.problem, cParam, C.int(nFolds), target)
+	printHandle, freePrintHandle := newPrintHandle(param.Print)
+	defer freePrintHandle()
+
+	C.cross_validation_wrap(cProblem, cParam, C.int(nFolds), target, printHandle)
 
 	classifications := make([]float64, nInstances)
 	for idx := range classifications {
@@ -44,3 +63,60 @@ func CrossValidation(problem *Problem, param Parameters, nFolds uint) ([]float64
 
 	return classifications, nil
 }
+
+// FindParameters uses liblinear's parameter search to find the cost
+// of constraint violation (and the sensitivity of the loss for support
+// vector regression) with the best cross-validation score. Candidate
+// parameters are evaluated with nFolds-fold cross-validation, starting
+// at startC and startP. If a start value is not positive, liblinear
+// chooses one.
+//
+// The search is supported for L2-regularized logistic regression
+// (primal), L2-regularized L2-loss support vector classification
+// (primal), and, with liblinear 2.30 or later, L2-regularized L2-loss
+// support vector regression (primal). If the linked liblinear version
+// does not support the search for the solver, an error that wraps
+// ErrUnsupported is returned. The other fields of param are used as-is.
+func FindParameters(problem *Problem, param Parameters, nFolds uint, startC, startP float64) (ParameterSearchResult, error) {
+	if err := problem.checkOpen(); err != nil {
+		return ParameterSearchResult{}, err
+	}
+
+	solver := param.SolverType.solverType
+	if solver != solverL2RLR && solver != solverL2RL2LossSvc && solver != solverL2RL2LossSvr {
+		return ParameterSearchResult{}, errors.New("Parameter search is only supported for the L2R_LR, L2R_L2LOSS_SVC, and L2R_L2LOSS_SVR solvers")
+	}
+
+	if nFolds < 2 {
+		return ParameterSearchResult{}, errors.New("Parameter search requires at least two folds")
+	}
+
+	cProblem, freeProblem, err := problem.trainingProblem()
+	if err != nil {
+		return ParameterSearchResult{}, err
+	}
+	defer freeProblem()
+
+	cParam, err := toCParameter(param, problem, cProblem)
+	if err != nil {
+		return ParameterSearchResult{}, err
+	}
+	defer freeParameter(cParam)
+
+	r := C.check_parameter_wrap(cProblem, cParam)
+	if r != nil {
+		msg := C.GoString(r)
+		return ParameterSearchResult{}, errors.New(msg)
+	}
+
+	printHandle, freePrintHandle := newPrintHandle(param.Print)
+	defer freePrintHandle()
+
+	var bestC, bestP, bestScore C.double
+	if C.find_parameters_wrap(cProblem, cParam, C.int(nFolds), C.double(startC),
+		C.double(startP), &bestC, &bestP, &bestScore, printHandle) == 0 {
+		return ParameterSearchResult{}, fmt.Errorf("%w: parameter search", ErrUnsupported)
+	}
+
+	return ParameterSearchResult{float64(bestC), float64(bestP), float64(bestScore)}, nil
+}
@@ -8,26 +8,26 @@ import "testing"
 
 func tenInstanceProblem(t *testing.T) *Problem {
 	problem := NewProblem()
-	problem.Add(TrainingInstance{0,
-		FromDenseVector([]float64{1, 1, 1, 0, 0})})
-	problem.Add(TrainingInstance{0,
-		FromDenseVector([]float64{1, 1, 1, 0, 0})})
-	problem.Add(TrainingInstance{0,
-		FromDenseVector([]float64{1, 1, 0, 0, 0})})
-	problem.Add(TrainingInstance{0,
-		FromDenseVector([]float64{1, 1, 0, 0, 0})})
-	problem.Add(TrainingInstance{0,
-		FromDenseVector([]float64{1, 1, 0, 0, 0})})
-	problem.Add(TrainingInstance{1,
-		FromDenseVector([]float64{0, 0, 1, 1, 1})})
-	problem.Add(TrainingInstance{1,
-		FromDenseVector([]float64{0, 0, 1, 1, 1})})
-	problem.Add(TrainingInstance{1,
-		FromDenseVector([]float64{0, 0, 0, 1, 1})})
-	problem.Add(TrainingInstance{1,
-		FromDenseVector([]float64{0, 0, 0, 1, 1})})
-	problem.Add(TrainingInstance{1,
-		FromDenseVector([]float64{0, 0, 0, 1, 1})})
+	problem.Add(TrainingInstance{Label: 0,
+		Features: FromDenseVector([]float64{1, 1, 1, 0, 0})})
+	problem.Add(TrainingInstance{Label: 0,
+		Features: FromDenseVector([]float64{1, 1, 1, 0, 0})})
+	problem.Add(TrainingInstance{Label: 0,
+		Features: FromDenseVector([]float64{1, 1, 0, 0, 0})})
+	problem.Add(TrainingInstance{Label: 0,
+		Features: FromDenseVector([]float64{1, 1, 0, 0, 0})})
+	problem.Add(TrainingInstance{Label: 0,
+		Features: FromDenseVector([]float64{1, 1, 0, 0, 0})})
+	problem.Add(TrainingInstance{Label: 1,
+		Features: FromDenseVector([]float64{0, 0, 1, 1, 1})})
+	problem.Add(TrainingInstance{Label: 1,
+		Features: FromDenseVector([]float64{0, 0, 1, 1, 1})})
+	problem.Add(TrainingInstance{Label: 1,
+		Features: FromDenseVector([]float64{0, 0, 0, 1, 1})})
+	problem.Add(TrainingInstance{Label: 1,
+		Features: FromDenseVector([]float64{0, 0, 0, 1, 1})})
+	problem.Add(TrainingInstance{Label: 1,
+		Features: FromDenseVector([]float64{0, 0, 0, 1, 1})})
 
 	return problem
 }
@@ -37,6 +37,9 @@ func TestCrossValidation(t *testing.T) {
 	param := DefaultParameters()
 
 	results, err := CrossValidation(problem, param, 10)
+	if err == ErrTrainingUnavailable {
+		t.Skip(err.Error())
+	}
 	if err != nil {
 		t.Errorf("Could not train model: %s", err.Error())
 	}
@@ -0,0 +1,112 @@
+// Copyright 2015 The golinear Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license
+// that can be found in the LICENSE file.
+
+package golinear
+
+import (
+	"errors"
+	"fmt"
+)
+
+// warmStartSolution converts the weights of a model to an initial
+// solution for training with the given problem. The initial solution is
+// laid out as the weights of the model that liblinear will train: the
+// classes are ordered as they are encountered in the problem and the
+// weights of the bias follow the weights of all features in the problem.
+func warmStartSolution(param Parameters, problem *Problem, m *linearModel) ([]float64, error) {
+	if param.SolverType.solverType != m.solverType {
+		return nil, fmt.Errorf("The initial model was trained with another solver: %s",
+			solverNames[m.solverType])
+	}
+
+	if (problem.Bias() >= 0) != (m.bias >= 0) {
+		return nil, errors.New("Either both or neither of the problem and the initial model should use a bias")
+	}
+
+	nFeatures := problem.nFeatures()
+	if nFeatures < m.nrFeature {
+		return nil, fmt.Errorf("The problem has fewer features (%d) than the initial model (%d)",
+			nFeatures, m.nrFeature)
+	}
+
+	nWeights := m.nrWeightVectors()
+
+	// Find the weight vector of the initial model for each class of the
+	// problem. Models without labels have a single weight vector.
+	order := []int{0}
+	negate := false
+	if m.labels != nil {
+		labels := problemLabels(problem)
+		if len(labels) != m.nrClass {
+			return nil, fmt.Errorf("The problem has %d classes, the initial model has %d classes",
+				len(labels), m.nrClass)
+		}
+
+		order = make([]int, len(labels))
+		for i, label := range labels {
+			order[i] = -1
+			for j, modelLabel := range m.labels {
+				if label == modelLabel {
+					order[i] = j
+				}
+			}
+
+			if order[i] == -1 {
+				return nil, fmt.Errorf("Label %d does not occur in the initial model", label)
+			}
+		}
+
+		// Two-class models have a single weight vector for the first
+		// class. If the classes are swapped, the weights are negated.
+		if nWeights == 1 {
+			negate = order[0] != 0
+			order = []int{0}
+		}
+	}
+
+	wSize := nFeatures
+	if m.bias >= 0 {
+		wSize++
+	}
+
+	initSol := make([]float64, wSize*nWeights)
+	for i := 0; i < m.wSize(); i++ {
+		row := i
+		if i == m.nrFeature {
+			// The bias is stored after the features of the problem.
+			row = nFeatures
+		}
+
+		for class := 0; class < nWeights; class++ {
+			w := m.w[i*nWeights+order[class]]
+			if negate {
+				w = -w
+			}
+			initSol[row*nWeights+class] = w
+		}
+	}
+
+	return initSol, nil
+}
+
+// problemLabels returns the labels of a classification problem in the
+// order used by liblinear: the order of first occurrence, except for
+// the labels -1 and +1, where +1 is always first.
+func problemLabels(problem *Problem) []int {
+	var labels []int
+	seen := make(map[int]bool)
+	for _, target := range problem.targets() {
+		label := int(target)
+		if !seen[label] {
+			labels = append(labels, label)
+			seen[label] = true
+		}
+	}
+
+	if len(labels) == 2 && labels[0] == -1 && labels[1] == 1 {
+		labels[0], labels[1] = 1, -1
+	}
+
+	return labels
+}
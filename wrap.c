@@ -2,12 +2,14 @@
 // Use of this source code is governed by a BSD-style license
 // that can be found in the LICENSE file.
 
+#include <stdio.h>
 #include <stdlib.h>
 #include <string.h>
 
 #include <linear.h>
 
 #include "wrap.h"
+#include "_cgo_export.h"
 
 #ifdef CV_OMP
 #include <omp.h>
@@ -70,21 +72,63 @@ problem_t *problem_new()
     free(problem);
     return NULL;
   }
+#ifdef LIBLINEAR_WEIGHTS
+  problem->W = malloc(0);
+  if (problem->W == NULL) {
+    free(problem->x);
+    free(problem->y);
+    free(problem);
+    return NULL;
+  }
+#endif
 
   return problem;
 }
 
 void problem_free(problem_t *problem)
 {
+#ifdef LIBLINEAR_WEIGHTS
+  free(problem->W);
+#endif
   free(problem->x);
   free(problem->y);
   free(problem);
 }
 
-void problem_add_train_inst(problem_t *problem, feature_node_t *nodes,
-  double label)
+// Returns 0 if memory could not be allocated, the problem is not
+// modified in that case.
+int problem_add_train_inst(problem_t *problem, feature_node_t *nodes,
+  double label, double weight)
 {
-  ++problem->l;
+  size_t l = (size_t) problem->l + 1;
+
+  double *y = realloc(problem->y, l * sizeof(double));
+  if (y == NULL) {
+    return 0;
+  }
+  problem->y = y;
+
+  feature_node_t **x = realloc(problem->x, l * sizeof(feature_node_t *));
+  if (x == NULL) {
+    return 0;
+  }
+  problem->x = x;
+
+#ifdef LIBLINEAR_WEIGHTS
+  double *W = realloc(problem->W, l * sizeof(double));
+  if (W == NULL) {
+    return 0;
+  }
+  problem->W = W;
+  problem->W[l - 1] = weight;
+#else
+  // Instance weights are checked by the caller.
+  (void) weight;
+#endif
+
+  problem->y[l - 1] = label;
+  problem->x[l - 1] = nodes;
+  problem->l = (int) l;
 
   // The number of features equals the highest feature index.
   feature_node_t *node;
@@ -92,10 +136,93 @@ void problem_add_train_inst(problem_t *problem, feature_node_t *nodes,
   	if (node->index > problem->n)
   		problem->n = node->index;
 
-  problem->y = realloc(problem->y, (size_t) problem->l * sizeof(double));
-  problem->y[problem->l - 1] = label;
-  problem->x = realloc(problem->x, (size_t) problem->l * sizeof(feature_node_t *));
-  problem->x[problem->l - 1] = nodes;
+  return 1;
+}
+
+double problem_weight(problem_t const *problem, size_t idx)
+{
+#ifdef LIBLINEAR_WEIGHTS
+  return problem->W[idx];
+#else
+  (void) problem;
+  (void) idx;
+  return 1.0;
+#endif
+}
+
+int problem_weights_supported()
+{
+#ifdef LIBLINEAR_WEIGHTS
+  return 1;
+#else
+  return 0;
+#endif
+}
+
+problem_t *problem_with_bias(problem_t const *problem)
+{
+  problem_t *biased = malloc(sizeof(problem_t));
+  if (biased == NULL) {
+    return NULL;
+  }
+
+  *biased = *problem;
+  biased->n = problem->n + 1;
+
+  // An empty problem has no instances or nodes. Do not allocate them:
+  // malloc(0) may return NULL, which the caller would treat as running
+  // out of memory.
+  if (problem->l == 0) {
+    biased->x = NULL;
+    return biased;
+  }
+
+  size_t n_nodes = 0;
+  int i;
+  for (i = 0; i < problem->l; ++i) {
+    feature_node_t *node;
+    for (node = problem->x[i]; node->index != -1; ++node)
+      ++n_nodes;
+    // Bias and terminator.
+    n_nodes += 2;
+  }
+
+  biased->x = malloc((size_t) problem->l * sizeof(feature_node_t *));
+  feature_node_t *x_space = malloc(n_nodes * sizeof(feature_node_t));
+  if (biased->x == NULL || x_space == NULL) {
+    free(biased->x);
+    free(x_space);
+    free(biased);
+    return NULL;
+  }
+
+  for (i = 0; i < problem->l; ++i) {
+    biased->x[i] = x_space;
+
+    feature_node_t *node;
+    for (node = problem->x[i]; node->index != -1; ++node)
+      *x_space++ = *node;
+
+    x_space->index = biased->n;
+    x_space->value = problem->bias;
+    ++x_space;
+
+    x_space->index = -1;
+    x_space->value = 0.0;
+    ++x_space;
+  }
+
+  return biased;
+}
+
+void problem_with_bias_free(problem_t *problem)
+{
+  // The nodes of all instances are allocated in one block.
+  if (problem->l > 0) {
+    free(problem->x[0]);
+  }
+  free(problem->x);
+  free(problem);
 }
 
 double problem_bias(problem_t *problem)
@@ -115,6 +242,11 @@ parameter_t *parameter_new()
     return NULL;
   }
   memset(param, 0, sizeof(parameter_t));
+#if LIBLINEAR_VERSION >= 240
+  // liblinear's defaults.
+  param->nu = 0.5;
+  param->regularize_bias = 1;
+#endif
   return param;
 }
 
@@ -128,6 +260,44 @@ void parameter_set_nthreads(parameter_t *param, int nthreads)
 #endif
 }
 
+int parameter_set_nu(parameter_t *param, double nu)
+{
+#if LIBLINEAR_VERSION >= 240
+  param->nu = nu;
+  return 1;
+#else
+  (void) param;
+  (void) nu;
+  return 0;
+#endif
+}
+
+// The parameter takes ownership of init_sol if the initial solution is
+// supported.
+int parameter_set_init_sol(parameter_t *param, double *init_sol)
+{
+#if LIBLINEAR_VERSION >= 210
+  param->init_sol = init_sol;
+  return 1;
+#else
+  (void) param;
+  (void) init_sol;
+  return 0;
+#endif
+}
+
+int parameter_set_regularize_bias(parameter_t *param, int regularize_bias)
+{
+#if LIBLINEAR_VERSION >= 240
+  param->regularize_bias = regularize_bias;
+  return 1;
+#else
+  (void) param;
+  (void) regularize_bias;
+  return 0;
+#endif
+}
+
 void parameter_free(parameter_t *param)
 {
   if (param->weight_label != NULL) {
@@ -138,6 +308,12 @@ void parameter_free(parameter_t *param)
     free(param->weight);
     param->weight = NULL;
   }
+#if LIBLINEAR_VERSION >= 210
+  if (param->init_sol != NULL) {
+    free(param->init_sol);
+    param->init_sol = NULL;
+  }
+#endif
 }
 
 double *double_new(size_t n)
@@ -160,15 +336,13 @@ int *labels_new(int n)
   return labels;
 }
 
-double *probs_new(model_t *model)
+double model_rho(model_t const *model)
 {
-  int nClasses = get_nr_class(model);
-  double *probs = malloc((size_t) nClasses * sizeof(double));
-  if (probs == NULL) {
-      return NULL;
-  }
-  memset(probs, 0, (size_t) nClasses * sizeof(double));
-  return probs;
+#if LIBLINEAR_VERSION >= 240
+  return model->rho;
+#else
+  return 0.0;
+#endif
 }
 
 double get_double_idx(double *arr, int idx)
@@ -191,66 +365,80 @@ void set_int_idx(int *arr, int idx, int val)
   arr[idx] = val;
 }
 
-char const *check_parameter_wrap(problem_t *prob, parameter_t *param)
-{
-  return check_parameter(prob, param);
-}
-
-void cross_validation_wrap(problem_t const *prob, parameter_t const *param,
-  int nr_fold, double *target)
-{
-  return cross_validation(prob, param, nr_fold, target);
-}
-
-
-void destroy_param_wrap(parameter_t* param)
-{
-  return destroy_param(param);
-}
-
-void free_and_destroy_model_wrap(model_t *model)
-{
-  free_and_destroy_model(&model);
-}
+// Handle of the Go print function of the training call that is running
+// on this thread, 0 to print to stdout.
+static __thread uintptr_t thread_print_handle = 0;
 
-void get_labels_wrap(model_t const *model, int *label)
+static void print_string(char const *s)
 {
-  get_labels(model, label);
-}
+  if (thread_print_handle == 0) {
+    fputs(s, stdout);
+    fflush(stdout);
+    return;
+  }
 
-int get_nr_class_wrap(model_t const *model)
-{
-  return get_nr_class(model);
+  goPrintString(thread_print_handle, (char *) s);
 }
 
-model_t *load_model_wrap(char const *filename)
+void print_init()
 {
-  return load_model(filename);
+  set_print_string_function(&print_string);
 }
 
-double predict_probability_wrap(model_t const *model, 
-    feature_node_t const *x, double *prob_estimates)
+char const *check_parameter_wrap(problem_t *prob, parameter_t *param)
 {
-  return predict_probability(model, x, prob_estimates);
+  return check_parameter(prob, param);
 }
 
-double predict_values_wrap(model_t const *model,
-    feature_node_t const *x, double *dec_values)
-{
-  return predict_values(model, x, dec_values);
+void cross_validation_wrap(problem_t const *prob, parameter_t const *param,
+  int nr_fold, double *target, uintptr_t print_handle)
+{
+  thread_print_handle = print_handle;
+  cross_validation(prob, param, nr_fold, target);
+  thread_print_handle = 0;
+}
+
+int find_parameters_wrap(problem_t const *prob, parameter_t const *param,
+  int nr_fold, double start_C, double start_p, double *best_C,
+  double *best_p, double *best_score, uintptr_t print_handle)
+{
+#if LIBLINEAR_VERSION >= 230
+  thread_print_handle = print_handle;
+  find_parameters(prob, param, nr_fold, start_C, start_p, best_C, best_p,
+    best_score);
+  thread_print_handle = 0;
+  return 1;
+#elif LIBLINEAR_VERSION >= 220
+  // Older versions can only search C for classification.
+  if (param->solver_type == L2R_L2LOSS_SVR) {
+    return 0;
+  }
+  thread_print_handle = print_handle;
+  find_parameter_C(prob, param, nr_fold, start_C, 1024, best_C, best_score);
+  thread_print_handle = 0;
+  *best_p = param->p;
+  return 1;
+#else
+  (void) print_handle;
+  return 0;
+#endif
 }
 
-int save_model_wrap(model_t const *model, char const *filename)
+void destroy_param_wrap(parameter_t* param)
 {
-  return save_model(filename, model);
+  return destroy_param(param);
 }
 
-model_t *train_wrap(problem_t *prob, parameter_t *param)
+void free_and_destroy_model_wrap(model_t *model)
 {
-  return train(prob, param);
+  free_and_destroy_model(&model);
 }
 
-double predict_wrap(model_t const *model, feature_node_t *nodes)
+model_t *train_wrap(problem_t *prob, parameter_t *param,
+  uintptr_t print_handle)
 {
-  return predict(model, nodes);
+  thread_print_handle = print_handle;
+  model_t *model = train(prob, param);
+  thread_print_handle = 0;
+  return model;
 }
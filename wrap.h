@@ -7,6 +7,7 @@
 
 #include <linear.h>
 #include <stddef.h>
+#include <stdint.h>
 
 typedef struct feature_node feature_node_t;
 typedef struct problem problem_t;
@@ -23,19 +24,28 @@ feature_node_t *nodes_vector_get(problem_t *problem, size_t idx);
 
 problem_t *problem_new();
 void problem_free(problem_t *problem);
-void problem_add_train_inst(problem_t *problem, feature_node_t *nodes,
-  double label);
+int problem_add_train_inst(problem_t *problem, feature_node_t *nodes,
+  double label, double weight);
+double problem_weight(problem_t const *problem, size_t idx);
+int problem_weights_supported();
+
+problem_t *problem_with_bias(problem_t const *problem);
+void problem_with_bias_free(problem_t *problem);
 
 double problem_bias(problem_t *problem);
 void set_problem_bias(problem_t *problem, double bias);
 
 parameter_t *parameter_new();
 void parameter_set_nthreads(parameter_t *param, int nthreads);
+int parameter_set_nu(parameter_t *param, double nu);
+int parameter_set_init_sol(parameter_t *param, double *init_sol);
+int parameter_set_regularize_bias(parameter_t *param, int regularize_bias);
 void parameter_free(parameter_t *param);
 
 int *labels_new(int n);
 
-double *probs_new(model_t *model);
+double model_rho(model_t const *model);
+
 double *double_new(size_t n);
 
 double get_double_idx(double *arr, int idx);
@@ -44,21 +54,18 @@ int get_int_idx(int *arr, int idx);
 void set_double_idx(double *arr, int idx, double val);
 void set_int_idx(int *arr, int idx, int val);
 
+void print_init();
+
 char const *check_parameter_wrap(problem_t *prob,
     parameter_t *param);
 void cross_validation_wrap(problem_t const *prob, parameter_t const *param,
-	int nr_fold, double *target);
+	int nr_fold, double *target, uintptr_t print_handle);
+int find_parameters_wrap(problem_t const *prob, parameter_t const *param,
+  int nr_fold, double start_C, double start_p, double *best_C,
+  double *best_p, double *best_score, uintptr_t print_handle);
 void destroy_param_wrap(parameter_t* param);
-void get_labels_wrap(model_t const *model, int *label);
-int get_nr_class_wrap(model_t const *model);
-model_t *load_model_wrap(char const *filename);
-double predict_wrap(model_t const *model, feature_node_t *nodes);
-double predict_probability_wrap(model_t const *model,
-	feature_node_t const *x, double *prob_estimates);
-double predict_values_wrap(model_t const *model,
-    feature_node_t const *x, double *dec_values);
-int save_model_wrap(model_t const *model, char const *filename);
-model_t *train_wrap(problem_t *prob, parameter_t *param);
+model_t *train_wrap(problem_t *prob, parameter_t *param,
+  uintptr_t print_handle);
 void free_and_destroy_model_wrap(model_t *model);
 
 #endif // WRAP_H